@@ -6,18 +6,25 @@ type Application struct {
 	ui      *UI
 }
 
-// NewApplication creates an Application with initialized internals
-func NewApplication() *Application {
-	network := NewNetwork()
+// NewApplication creates an Application with initialized internals using the provided `config`, `bookmarks` and `history`
+func NewApplication(config *Config, bookmarks *Bookmarks, history *History) *Application {
+	network := NewNetwork(config)
 	return &Application{
 		network: network,
-		ui:      NewUI(network),
+		ui:      NewUI(network, config, bookmarks, history),
 	}
 }
 
-// Run starts the internals and ensure they stop correctly, `address` is the initial Gopher server requested
+// Run starts the internals and ensure they stop correctly, `address` is the initial Gopher server requested (the bookmarks page if empty)
 func (app *Application) Run(address string) {
 	app.network.Start()
 	defer app.network.Stop()
 	app.ui.Run(address)
 }
+
+// Restore is like Run but reopens the last session instead
+func (app *Application) Restore() {
+	app.network.Start()
+	defer app.network.Stop()
+	app.ui.Restore()
+}
@@ -0,0 +1,344 @@
+package taupe
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/LouisBrunner/taupe/core"
+)
+
+// BookmarksAddress is the address of the bookmarks page, its folders are at `BookmarksAddress/<folder>`
+const BookmarksAddress = "about:bookmarks"
+
+// Bookmark is a location saved by the user, an empty Folder means it is at the top of the bookmarks page
+type Bookmark struct {
+	Title   string `json:"title"`
+	Address string `json:"address"`
+	Folder  string `json:"folder,omitempty"`
+}
+
+// Bookmarks are the locations saved by the user, kept in a JSON file
+type Bookmarks struct {
+	path    string
+	Folders []string   `json:"folders"`
+	Items   []Bookmark `json:"bookmarks"`
+}
+
+// BookmarksPath returns the default location of the bookmarks file
+func BookmarksPath() string {
+	return filepath.Join(ConfigDir(), "bookmarks.json")
+}
+
+// LoadBookmarks reads the bookmarks saved at `path`, a missing file is not an error
+func LoadBookmarks(path string) (*Bookmarks, error) {
+	bookmarks := &Bookmarks{path: path, Folders: []string{}, Items: []Bookmark{}}
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return bookmarks, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read bookmarks `%s`: %s", path, err)
+	}
+	if err = json.Unmarshal(content, bookmarks); err != nil {
+		return nil, fmt.Errorf("invalid bookmarks `%s`: %s", path, err)
+	}
+	// Older files may bookmark a page twice in a folder, only the first one is kept
+	items := bookmarks.Items
+	bookmarks.Items = []Bookmark{}
+	for _, item := range items {
+		bookmarks.Add(item)
+	}
+	return bookmarks, nil
+}
+
+// Save writes the bookmarks back to their file
+func (bookmarks *Bookmarks) Save() error {
+	content, err := json.MarshalIndent(bookmarks, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(bookmarks.path), 0755); err != nil {
+		return fmt.Errorf("cannot create directory for `%s`: %s", bookmarks.path, err)
+	}
+	if err = ioutil.WriteFile(bookmarks.path, content, 0644); err != nil {
+		return fmt.Errorf("cannot save bookmarks `%s`: %s", bookmarks.path, err)
+	}
+	return nil
+}
+
+// Add saves a new bookmark, creating its folder if needed, a page can only be bookmarked once in a folder
+func (bookmarks *Bookmarks) Add(bookmark Bookmark) error {
+	if bookmarks.contains(bookmark) {
+		return fmt.Errorf("`%s` is already bookmarked in this folder", bookmark.Address)
+	}
+	bookmarks.AddFolder(bookmark.Folder)
+	bookmarks.Items = append(bookmarks.Items, bookmark)
+	return nil
+}
+
+// AddFolder creates an empty folder, nothing happens if it already exists
+func (bookmarks *Bookmarks) AddFolder(name string) {
+	if name == "" || bookmarks.hasFolder(name) {
+		return
+	}
+	bookmarks.Folders = append(bookmarks.Folders, name)
+}
+
+func (bookmarks *Bookmarks) hasFolder(name string) bool {
+	for _, folder := range bookmarks.Folders {
+		if folder == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Rename changes the title of the bookmark at `index`
+func (bookmarks *Bookmarks) Rename(index int, title string) {
+	bookmarks.Items[index].Title = title
+}
+
+// Move puts the bookmark at `index` in `folder`, creating it if needed
+func (bookmarks *Bookmarks) Move(index int, folder string) error {
+	address := bookmarks.Items[index].Address
+	if other := bookmarks.indexOf(address, folder); other >= 0 && other != index {
+		return fmt.Errorf("`%s` is already bookmarked in `%s`", address, folder)
+	}
+	bookmarks.AddFolder(folder)
+	bookmarks.Items[index].Folder = folder
+	return nil
+}
+
+// Delete removes the bookmark at `index`
+func (bookmarks *Bookmarks) Delete(index int) {
+	bookmarks.Items = append(bookmarks.Items[:index], bookmarks.Items[index+1:]...)
+}
+
+// RenameFolder renames the folder `name` and moves its bookmarks along, `renamed` can't be an existing folder
+func (bookmarks *Bookmarks) RenameFolder(name, renamed string) error {
+	if renamed != name && bookmarks.hasFolder(renamed) {
+		return fmt.Errorf("folder `%s` already exists", renamed)
+	}
+	for i := range bookmarks.Folders {
+		if bookmarks.Folders[i] == name {
+			bookmarks.Folders[i] = renamed
+		}
+	}
+	for i := range bookmarks.Items {
+		if bookmarks.Items[i].Folder == name {
+			bookmarks.Items[i].Folder = renamed
+		}
+	}
+	return nil
+}
+
+// DeleteFolder removes the folder `name` and all its bookmarks
+func (bookmarks *Bookmarks) DeleteFolder(name string) {
+	folders := []string{}
+	for _, folder := range bookmarks.Folders {
+		if folder != name {
+			folders = append(folders, folder)
+		}
+	}
+	items := []Bookmark{}
+	for _, item := range bookmarks.Items {
+		if item.Folder != name {
+			items = append(items, item)
+		}
+	}
+	bookmarks.Folders, bookmarks.Items = folders, items
+}
+
+// Import adds the bookmarks listed in the file at `path` (a gophermap or a list of URLs), returning how many were new
+// `server` (`host` or `host:port`) is used for the items of a gophermap which don't give theirs
+func (bookmarks *Bookmarks) Import(path, server string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("cannot read `%s`: %s", path, err)
+	}
+	defer file.Close()
+
+	var imported []Bookmark
+	if isGophermap(path) {
+		imported, err = parseGophermap(file, server)
+	} else {
+		imported, err = parseURLList(file)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("cannot import `%s`: %s", path, err)
+	}
+
+	added := 0
+	for _, bookmark := range imported {
+		if bookmarks.Add(bookmark) == nil {
+			added++
+		}
+	}
+	return added, nil
+}
+
+func (bookmarks *Bookmarks) contains(bookmark Bookmark) bool {
+	return bookmarks.indexOf(bookmark.Address, bookmark.Folder) >= 0
+}
+
+// indexOf returns the index of the bookmark of `address` in `folder` (there is at most one), -1 if there is none
+func (bookmarks *Bookmarks) indexOf(address, folder string) int {
+	for i, item := range bookmarks.Items {
+		if item.Address == address && item.Folder == folder {
+			return i
+		}
+	}
+	return -1
+}
+
+// Export writes the bookmarks to the file at `path`, as a gophermap if its name says so, as a list of URLs otherwise,
+// returning how many were written (the ones which aren't Gopher addresses, e.g. `about:history`, are left out)
+func (bookmarks *Bookmarks) Export(path string) (int, error) {
+	content, count := formatURLList(bookmarks.sorted())
+	if isGophermap(path) {
+		content, count = formatGophermap(bookmarks.sorted())
+	}
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		return 0, fmt.Errorf("cannot export to `%s`: %s", path, err)
+	}
+	return count, nil
+}
+
+// sorted returns the bookmarks grouped by folder, the ones without a folder first
+func (bookmarks *Bookmarks) sorted() []Bookmark {
+	result := bookmarks.inFolder("")
+	for _, folder := range bookmarks.Folders {
+		result = append(result, bookmarks.inFolder(folder)...)
+	}
+	return result
+}
+
+func (bookmarks *Bookmarks) inFolder(folder string) []Bookmark {
+	result := []Bookmark{}
+	for _, item := range bookmarks.Items {
+		if item.Folder == folder {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// escapeSegment makes `segment` safe to use after the `/` of a local address (e.g. BookmarksAddress)
+func escapeSegment(segment string) string {
+	return strings.Replace(strings.Replace(segment, "%", "%25", -1), "/", "%2F", -1)
+}
+
+func unescapeSegment(segment string) string {
+	return strings.Replace(strings.Replace(segment, "%2F", "/", -1), "%25", "%", -1)
+}
+
+func isGophermap(path string) bool {
+	name := strings.ToLower(filepath.Base(path))
+	return name == "gophermap" || strings.HasSuffix(name, ".gophermap")
+}
+
+// Folders are written as informational lines, followed by their bookmarks
+func formatGophermap(items []Bookmark) (string, int) {
+	var result bytes.Buffer
+	folder, count := "", 0
+	for _, item := range items {
+		url, err := core.ParseURL(item.Address)
+		if err != nil {
+			continue
+		}
+		if item.Folder != folder {
+			folder = item.Folder
+			fmt.Fprintf(&result, "i%s\t\terror.host\t1\r\n", folder)
+		}
+		fmt.Fprintf(&result, "%c%s\t%s\t%s\t%s\r\n", url.Type, item.Title, url.Selector, url.Host, url.Port)
+		count++
+	}
+	result.WriteString(".\r\n")
+	return result.String(), count
+}
+
+// A non-empty informational line followed by a link names the folder of the next links, the other ones (blank lines, text) are ignored
+func parseGophermap(reader io.Reader, server string) ([]Bookmark, error) {
+	host, port := server, core.DefaultPort
+	if strings.Contains(server, ":") {
+		var err error
+		if host, port, err = net.SplitHostPort(server); err != nil {
+			return nil, fmt.Errorf("invalid server `%s`: %s", server, err)
+		}
+	}
+	records, _, err := core.ParseMenu(reader, core.MenuOptions{Host: host, Port: port})
+	if err != nil {
+		return nil, err
+	}
+	items := []Bookmark{}
+	folder := ""
+	for i, record := range records {
+		if record.Type == core.TypeInformational {
+			name := strings.TrimSpace(record.Display)
+			if name != "" && i+1 < len(records) && records[i+1].IsLink() {
+				folder = name
+			}
+		} else if record.IsLink() && record.Address != "" {
+			if _, err := core.ParseURL(record.Address); err != nil {
+				return nil, fmt.Errorf("`%s` has no server, give the one of the gophermap", record.Display)
+			}
+			items = append(items, Bookmark{Title: record.Display, Address: record.Address, Folder: folder})
+		}
+	}
+	return items, nil
+}
+
+const urlListFolder = "# Folder: "
+
+// Each line is an URL optionally followed by a title, folders are written as `# Folder: <name>` comments
+func formatURLList(items []Bookmark) (string, int) {
+	var result bytes.Buffer
+	folder, count := "", 0
+	for _, item := range items {
+		if _, err := core.ParseURL(item.Address); err != nil {
+			continue
+		}
+		if item.Folder != folder {
+			folder = item.Folder
+			fmt.Fprintf(&result, "%s%s\n", urlListFolder, folder)
+		}
+		fmt.Fprintf(&result, "%s %s\n", item.Address, item.Title)
+		count++
+	}
+	return result.String(), count
+}
+
+func parseURLList(reader io.Reader) ([]Bookmark, error) {
+	items := []Bookmark{}
+	folder := ""
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, urlListFolder) {
+			folder = strings.TrimSpace(line[len(urlListFolder):])
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.SplitN(line, " ", 2)
+		address, err := normalizeAddress(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		title := address
+		if len(fields) > 1 && strings.TrimSpace(fields[1]) != "" {
+			title = strings.TrimSpace(fields[1])
+		}
+		items = append(items, Bookmark{Title: title, Address: address, Folder: folder})
+	}
+	return items, scanner.Err()
+}
@@ -0,0 +1,108 @@
+package taupe
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testBookmarks() *Bookmarks {
+	bookmarks := &Bookmarks{Folders: []string{}, Items: []Bookmark{}}
+	bookmarks.Add(Bookmark{Title: "Floodgap", Address: "gopher://gopher.floodgap.com/1"})
+	bookmarks.Add(Bookmark{Title: "SDF phlogs", Address: "gopher://sdf.org/1/phlogs", Folder: "Phlogs"})
+	bookmarks.Add(Bookmark{Title: "About", Address: "gopher://sdf.org:7070/0/about", Folder: "Phlogs"})
+	return bookmarks
+}
+
+func TestBookmarksFolders(t *testing.T) {
+	bookmarks := testBookmarks()
+	assert.Equal(t, []string{"Phlogs"}, bookmarks.Folders)
+	assert.Equal(t, 2, bookmarks.indexOf("gopher://sdf.org:7070/0/about", "Phlogs"))
+	assert.Equal(t, -1, bookmarks.indexOf("gopher://sdf.org:7070/0/about", ""))
+
+	assert.NoError(t, bookmarks.Move(0, "Search"))
+	assert.Equal(t, []string{"Phlogs", "Search"}, bookmarks.Folders)
+	assert.Equal(t, "Search", bookmarks.Items[0].Folder)
+
+	assert.Error(t, bookmarks.RenameFolder("Phlogs", "Search"))
+	assert.NoError(t, bookmarks.RenameFolder("Phlogs", "Blogs"))
+	assert.Equal(t, []string{"Blogs", "Search"}, bookmarks.Folders)
+	assert.Len(t, bookmarks.inFolder("Blogs"), 2)
+
+	bookmarks.DeleteFolder("Blogs")
+	assert.Equal(t, []string{"Search"}, bookmarks.Folders)
+	assert.Len(t, bookmarks.Items, 1)
+
+	assert.Error(t, bookmarks.Add(Bookmark{Title: "Again", Address: "gopher://gopher.floodgap.com/1", Folder: "Search"}))
+	assert.NoError(t, bookmarks.Add(Bookmark{Title: "Again", Address: "gopher://gopher.floodgap.com/1"}))
+	assert.Error(t, bookmarks.Move(1, "Search"))
+	bookmarks.Delete(1)
+
+	bookmarks.Rename(0, "Floodgap Systems")
+	bookmarks.Delete(0)
+	assert.Len(t, bookmarks.Items, 0)
+}
+
+func TestGophermapBookmarks(t *testing.T) {
+	bookmarks := testBookmarks()
+	gophermap, count := formatGophermap(bookmarks.sorted())
+	assert.Equal(t, 3, count)
+	assert.Equal(t, "1Floodgap\t\tgopher.floodgap.com\t70\r\n"+
+		"iPhlogs\t\terror.host\t1\r\n"+
+		"1SDF phlogs\t/phlogs\tsdf.org\t70\r\n"+
+		"0About\t/about\tsdf.org\t7070\r\n"+
+		".\r\n", gophermap)
+
+	items, err := parseGophermap(strings.NewReader(gophermap), "")
+	assert.NoError(t, err)
+	assert.Equal(t, bookmarks.Items, items)
+
+	served := "iWelcome to my hole\r\n" +
+		"i\r\n" +
+		"iLinks\r\n" +
+		"1Phlog\t/phlog\r\n" +
+		"0About\t/about\tsdf.org\t70\r\n" +
+		"i\r\n" +
+		"iThanks for visiting\r\n"
+	items, err = parseGophermap(strings.NewReader(served), "example.org:7070")
+	assert.NoError(t, err)
+	assert.Equal(t, []Bookmark{
+		{Title: "Phlog", Address: "gopher://example.org:7070/1/phlog", Folder: "Links"},
+		{Title: "About", Address: "gopher://sdf.org/0/about", Folder: "Links"},
+	}, items)
+
+	_, err = parseGophermap(strings.NewReader(served), "")
+	assert.Error(t, err)
+}
+
+func TestURLListBookmarks(t *testing.T) {
+	bookmarks := testBookmarks()
+	list, count := formatURLList(bookmarks.sorted())
+	assert.Equal(t, 3, count)
+	assert.Equal(t, "gopher://gopher.floodgap.com/1 Floodgap\n"+
+		"# Folder: Phlogs\n"+
+		"gopher://sdf.org/1/phlogs SDF phlogs\n"+
+		"gopher://sdf.org:7070/0/about About\n", list)
+
+	items, err := parseURLList(strings.NewReader(list))
+	assert.NoError(t, err)
+	assert.Equal(t, bookmarks.Items, items)
+
+	items, err = parseURLList(strings.NewReader("# shared start pages\n\nsdf.org\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []Bookmark{{Title: "gopher://sdf.org/1", Address: "gopher://sdf.org/1"}}, items)
+
+	_, err = parseURLList(strings.NewReader("http://example.com/\n"))
+	assert.Error(t, err)
+
+	_, count = formatURLList([]Bookmark{{Title: "History", Address: HistoryAddress}})
+	assert.Equal(t, 0, count)
+}
+
+func TestBookmarksFolderAddress(t *testing.T) {
+	cases := []string{"Phlogs", "a/b", "100%", ""}
+	for _, test := range cases {
+		assert.Equal(t, test, bookmarksFolder(bookmarkFolderAddress(test)))
+	}
+}
@@ -9,31 +9,67 @@ import (
 )
 
 func usage() {
-	fmt.Printf("Usage: %s [OPTIONS] url\n", os.Args[0])
+	fmt.Printf("Usage: %s [OPTIONS] [url]\n", os.Args[0])
 	flag.PrintDefaults()
 }
 
 type args struct {
-	address string
+	address     string
+	config      string
+	downloadDir string
+	restore     bool
+	offline     bool
 }
 
 func parseArgs() *args {
-	requiredArgs := 1
+	maxArgs := 1
 
+	config := flag.String("config", taupe.ConfigPath(), "path of the configuration file")
+	downloadDir := flag.String("download-dir", "", "directory where downloaded files are saved")
+	restore := flag.Bool("restore", false, "reopen the last session instead of an url")
+	offline := flag.Bool("offline", false, "only browse cached pages, without connecting to any server")
 	flag.Parse()
 
-	if flag.NArg() != requiredArgs {
+	if flag.NArg() > maxArgs || (*restore && flag.NArg() > 0) {
 		flag.Usage()
 		os.Exit(1)
 	}
 
-	return &args{address: flag.Arg(0)}
+	return &args{address: flag.Arg(0), config: *config, downloadDir: *downloadDir, restore: *restore, offline: *offline}
 }
 
 func main() {
 	flag.Usage = usage
 	args := parseArgs()
 
-	app := taupe.NewApplication()
-	app.Run(args.address)
+	config, err := taupe.LoadConfig(args.config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if args.downloadDir != "" {
+		config.DownloadDir = args.downloadDir
+	}
+	if args.offline {
+		config.Cache.Offline = true
+	}
+
+	bookmarks, err := taupe.LoadBookmarks(config.Bookmarks)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	history, err := taupe.LoadHistory(config.History.File, config.History.Limit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	app := taupe.NewApplication(config, bookmarks, history)
+	if args.restore {
+		app.Restore()
+	} else {
+		app.Run(args.address)
+	}
 }
@@ -0,0 +1,271 @@
+package taupe
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/LouisBrunner/taupe/core"
+)
+
+// Config represents the user settings shared by the different parts of the application
+type Config struct {
+	DownloadDir string            `json:"download_dir"`
+	Timeouts    ConfigTimeouts    `json:"timeouts"`
+	Connections ConfigConnections `json:"connections"`
+	TLS         ConfigTLS         `json:"tls"`
+	Bookmarks   string            `json:"bookmarks"`
+	History     ConfigHistory     `json:"history"`
+	Cache       ConfigCache       `json:"cache"`
+	Charset     ConfigCharset     `json:"charset"`
+	Keys        ConfigKeys        `json:"keys"`
+	Theme       ConfigTheme       `json:"theme"`
+	Links       ConfigLinks       `json:"links"`
+	Mouse       ConfigMouse       `json:"mouse"`
+	// Opener is the program which opens the links taupe cannot show itself (e.g. web pages)
+	Opener string `json:"opener"`
+}
+
+// ConfigCache controls how many pages are kept in memory, for how long they are used instead of requesting them again
+// and where they are stored on disk (an empty `Dir`, the default, disables it as nothing is ever removed from it)
+// `Offline` only shows cached pages, without ever connecting to a server
+type ConfigCache struct {
+	Size    int      `json:"size"`
+	TTL     Duration `json:"ttl"`
+	Dir     string   `json:"dir"`
+	Offline bool     `json:"offline"`
+}
+
+// ConfigKeys selects the key bindings: a `Preset` (`default`, `vi` or `emacs`) which `Bindings` can change,
+// each of them mapping an action to its key sequences (e.g. `"top": ["gg", "Home"]`)
+type ConfigKeys struct {
+	Preset   string              `json:"preset"`
+	Bindings map[string][]string `json:"bindings"`
+}
+
+// ConfigMouse controls the mouse: when `Enabled`, a click selects a link and a double click follows it
+// (a single click with `SingleClick`) and the wheel scrolls the page
+type ConfigMouse struct {
+	Enabled     bool `json:"enabled"`
+	SingleClick bool `json:"single_click"`
+}
+
+// ConfigLinks controls how links are reached by number: `Numbers` shows the number of each link of menus
+// and `Follow` opens the link whose number was typed instead of only selecting it
+type ConfigLinks struct {
+	Numbers bool `json:"numbers"`
+	Follow  bool `json:"follow"`
+}
+
+// ConfigTheme selects the colors of the UI: a built-in theme (`default`, `solarized` or `monochrome`) which the styles
+// of `File` (a JSON object) then `Styles` can change, each of them mapping an element to its style (e.g. `"menu": "blue bold"`)
+type ConfigTheme struct {
+	Name   string            `json:"name"`
+	File   string            `json:"file"`
+	Styles map[string]string `json:"styles"`
+}
+
+// ConfigCharset controls how the responses of servers which don't use UTF-8 are decoded (e.g. `latin-1` or `cp437`)
+// `Hosts` overrides `Default` for some servers, given as `host` or `host:port`
+type ConfigCharset struct {
+	Default string            `json:"default"`
+	Hosts   map[string]string `json:"hosts"`
+}
+
+// ConfigHistory controls where the visited pages are logged and how many of them are kept, a zero `Limit` keeps them all
+type ConfigHistory struct {
+	File  string `json:"file"`
+	Limit int    `json:"limit"`
+}
+
+// ConfigTLS controls how the network uses TLS
+// `Upgrade` tries TLS first on gopher:// addresses and falls back to plain text when the server doesn't support it
+// `KnownHosts` is the file where the certificate of each server is pinned on first use
+type ConfigTLS struct {
+	Upgrade    bool   `json:"upgrade"`
+	KnownHosts string `json:"known_hosts"`
+}
+
+// ConfigConnections limits how many requests the network runs at the same time
+type ConfigConnections struct {
+	Total   int `json:"total"`
+	PerHost int `json:"per_host"`
+}
+
+// ConfigTimeouts limits how long the network waits on a server, a zero value disables the limit
+// `Request` caps the whole request but doesn't apply to downloads
+type ConfigTimeouts struct {
+	Dial    Duration `json:"dial"`
+	Read    Duration `json:"read"`
+	Request Duration `json:"request"`
+}
+
+// Duration is a time.Duration written as a string (e.g. "1m30s") in the configuration file
+type Duration struct {
+	time.Duration
+}
+
+// UnmarshalJSON parses a Duration from a JSON string
+func (duration *Duration) UnmarshalJSON(data []byte) error {
+	var source string
+	if err := json.Unmarshal(data, &source); err != nil {
+		return fmt.Errorf("duration should be a string: %s", err)
+	}
+	parsed, err := time.ParseDuration(source)
+	if err != nil {
+		return err
+	}
+	duration.Duration = parsed
+	return nil
+}
+
+// MarshalJSON writes a Duration as a JSON string
+func (duration Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(duration.String())
+}
+
+// DefaultConfig returns the settings used when the user didn't provide any
+func DefaultConfig() *Config {
+	return &Config{
+		DownloadDir: defaultDownloadDir(),
+		Timeouts: ConfigTimeouts{
+			Dial:    Duration{10 * time.Second},
+			Read:    Duration{30 * time.Second},
+			Request: Duration{time.Minute},
+		},
+		Connections: ConfigConnections{
+			Total:   8,
+			PerHost: 2,
+		},
+		TLS: ConfigTLS{
+			KnownHosts: filepath.Join(ConfigDir(), "known_hosts"),
+		},
+		Bookmarks: BookmarksPath(),
+		History: ConfigHistory{
+			File:  HistoryPath(),
+			Limit: 1000,
+		},
+		Cache: ConfigCache{
+			Size: 100,
+			TTL:  Duration{5 * time.Minute},
+		},
+		Charset: ConfigCharset{
+			Default: core.CharsetUTF8.Name,
+			Hosts:   map[string]string{},
+		},
+		Keys: ConfigKeys{
+			Preset: "default",
+		},
+		Theme: ConfigTheme{
+			Name: "default",
+		},
+		Links: ConfigLinks{
+			Follow: true,
+		},
+		Mouse: ConfigMouse{
+			Enabled: true,
+		},
+		Opener: defaultOpener(),
+	}
+}
+
+// LoadConfig reads the JSON file at `path` on top of the default settings, a missing file is not an error
+func LoadConfig(path string) (*Config, error) {
+	config := DefaultConfig()
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return config, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read config `%s`: %s", path, err)
+	}
+	if err = json.Unmarshal(content, config); err != nil {
+		return nil, fmt.Errorf("invalid config `%s`: %s", path, err)
+	}
+	config.DownloadDir = expandHome(config.DownloadDir)
+	config.TLS.KnownHosts = expandHome(config.TLS.KnownHosts)
+	config.Bookmarks = expandHome(config.Bookmarks)
+	config.History.File = expandHome(config.History.File)
+	config.Cache.Dir = expandHome(config.Cache.Dir)
+	config.Theme.File = expandHome(config.Theme.File)
+	if err = config.Charset.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config `%s`: %s", path, err)
+	}
+	if _, err = NewKeymap(config.Keys); err != nil {
+		return nil, fmt.Errorf("invalid config `%s`: %s", path, err)
+	}
+	if _, err = NewTheme(config.Theme); err != nil {
+		return nil, fmt.Errorf("invalid config `%s`: %s", path, err)
+	}
+	return config, nil
+}
+
+func (charset ConfigCharset) validate() error {
+	if _, err := core.LookupCharset(charset.Default); err != nil {
+		return err
+	}
+	for host, name := range charset.Hosts {
+		if _, err := core.LookupCharset(name); err != nil {
+			return fmt.Errorf("%s (for `%s`)", err, host)
+		}
+	}
+	return nil
+}
+
+// lookup returns the charset used by the server at `host` (without port) and `hostPort`
+func (charset ConfigCharset) lookup(host, hostPort string) (*core.Charset, error) {
+	name, found := charset.Hosts[hostPort]
+	if !found {
+		name, found = charset.Hosts[host]
+	}
+	if !found {
+		name = charset.Default
+	}
+	return core.LookupCharset(name)
+}
+
+// ConfigDir returns the directory where taupe keeps its files, following the XDG convention
+func ConfigDir() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		dir = filepath.Join(os.Getenv("HOME"), ".config")
+	}
+	return filepath.Join(dir, "taupe")
+}
+
+// ConfigPath returns the default location of the configuration file
+func ConfigPath() string {
+	return filepath.Join(ConfigDir(), "config.json")
+}
+
+func defaultOpener() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "open"
+	case "windows":
+		return ""
+	}
+	return "xdg-open"
+}
+
+func defaultDownloadDir() string {
+	if dir := os.Getenv("XDG_DOWNLOAD_DIR"); dir != "" {
+		return dir
+	}
+	if home := os.Getenv("HOME"); home != "" {
+		return filepath.Join(home, "Downloads")
+	}
+	return "."
+}
+
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		return filepath.Join(os.Getenv("HOME"), path[1:])
+	}
+	return path
+}
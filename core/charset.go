@@ -0,0 +1,100 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Charset decodes the responses of servers which don't use UTF-8, each byte above 0x7F maps to a character
+type Charset struct {
+	Name  string
+	table *[128]rune
+}
+
+// CharsetUTF8 is the charset used by default, responses are read as is
+var CharsetUTF8 = &Charset{Name: "utf-8"}
+
+var latin1Table = func() *[128]rune {
+	table := [128]rune{}
+	for i := range table {
+		table[i] = rune(0x80 + i)
+	}
+	return &table
+}()
+
+var cp437Table = &[128]rune{
+	'Ç', 'ü', 'é', 'â', 'ä', 'à', 'å', 'ç', 'ê', 'ë', 'è', 'ï', 'î', 'ì', 'Ä', 'Å',
+	'É', 'æ', 'Æ', 'ô', 'ö', 'ò', 'û', 'ù', 'ÿ', 'Ö', 'Ü', '¢', '£', '¥', '₧', 'ƒ',
+	'á', 'í', 'ó', 'ú', 'ñ', 'Ñ', 'ª', 'º', '¿', '⌐', '¬', '½', '¼', '¡', '«', '»',
+	'░', '▒', '▓', '│', '┤', '╡', '╢', '╖', '╕', '╣', '║', '╗', '╝', '╜', '╛', '┐',
+	'└', '┴', '┬', '├', '─', '┼', '╞', '╟', '╚', '╔', '╩', '╦', '╠', '═', '╬', '╧',
+	'╨', '╤', '╥', '╙', '╘', '╒', '╓', '╫', '╪', '┘', '┌', '█', '▄', '▌', '▐', '▀',
+	'α', 'ß', 'Γ', 'π', 'Σ', 'σ', 'µ', 'τ', 'Φ', 'Θ', 'Ω', 'δ', '∞', 'φ', 'ε', '∩',
+	'≡', '±', '≥', '≤', '⌠', '⌡', '÷', '≈', '°', '∙', '·', '√', 'ⁿ', '²', '■', ' ',
+}
+
+// LookupCharset returns the charset called `name` (e.g. `utf-8`, `latin-1` or `cp437`), an empty name means UTF-8
+func LookupCharset(name string) (*Charset, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "utf-8", "utf8":
+		return CharsetUTF8, nil
+	case "latin-1", "latin1", "iso-8859-1", "iso8859-1":
+		return &Charset{Name: "latin-1", table: latin1Table}, nil
+	case "cp437", "ibm437", "dos":
+		return &Charset{Name: "cp437", table: cp437Table}, nil
+	}
+	return nil, fmt.Errorf("unknown charset `%s`", name)
+}
+
+// Reader returns a reader converting the content of `reader` to UTF-8
+func (charset *Charset) Reader(reader io.Reader) io.Reader {
+	if charset.table == nil {
+		return reader
+	}
+	return &charsetReader{charset: charset, source: reader, input: make([]byte, 1024)}
+}
+
+// Decode converts `data` to UTF-8
+func (charset *Charset) Decode(data []byte) string {
+	if charset.table == nil {
+		return string(data)
+	}
+	return string(charset.decode(nil, data))
+}
+
+func (charset *Charset) decode(output, data []byte) []byte {
+	var encoded [utf8.UTFMax]byte
+	for _, char := range data {
+		if char < 0x80 {
+			output = append(output, char)
+		} else {
+			size := utf8.EncodeRune(encoded[:], charset.table[char-0x80])
+			output = append(output, encoded[:size]...)
+		}
+	}
+	return output
+}
+
+type charsetReader struct {
+	charset *Charset
+	source  io.Reader
+	input   []byte
+	pending []byte
+	err     error
+}
+
+func (reader *charsetReader) Read(buffer []byte) (int, error) {
+	for len(reader.pending) == 0 {
+		if reader.err != nil {
+			return 0, reader.err
+		}
+		var read int
+		read, reader.err = reader.source.Read(reader.input)
+		reader.pending = reader.charset.decode(reader.pending[:0], reader.input[:read])
+	}
+	copied := copy(buffer, reader.pending)
+	reader.pending = reader.pending[copied:]
+	return copied, nil
+}
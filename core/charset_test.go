@@ -0,0 +1,56 @@
+package core
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCharsetDecode(t *testing.T) {
+	cases := []struct {
+		charset string
+		input   string
+		output  string
+	}{
+		{"", "caf\xc3\xa9", "café"},
+		{"UTF-8", "caf\xc3\xa9", "café"},
+		{"latin-1", "caf\xe9 \xa3", "café £"},
+		{"iso-8859-1", "\xff", "ÿ"},
+		{"cp437", "\xc9\xcd\xbb caf\x82", "╔═╗ café"},
+		{"ibm437", "\xe1\xf8", "ß°"},
+	}
+	for _, test := range cases {
+		charset, err := LookupCharset(test.charset)
+		if assert.NoError(t, err, "Expected %q to be found", test.charset) {
+			assert.Equal(t, test.output, charset.Decode([]byte(test.input)))
+			content, _ := ioutil.ReadAll(charset.Reader(strings.NewReader(test.input)))
+			assert.Equal(t, test.output, string(content))
+		}
+	}
+}
+
+func TestFailLookupCharset(t *testing.T) {
+	_, err := LookupCharset("ebcdic")
+	if assert.Error(t, err) {
+		assert.Equal(t, "unknown charset `ebcdic`", err.Error())
+	}
+}
+
+func TestStringWidth(t *testing.T) {
+	cases := []struct {
+		input  string
+		output int
+	}{
+		{"abc", 3},
+		{"café", 4},
+		{"cafe\u0301", 4},
+		{"日本語", 6},
+		{"a\u200bb", 2},
+		{"┌─┐", 3},
+	}
+	for _, test := range cases {
+		assert.Equal(t, test.output, StringWidth(test.input), "Unexpected width for %q", test.input)
+	}
+}
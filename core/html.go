@@ -0,0 +1,391 @@
+package core
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// HTMLLink is a link found in a HTML document, shown in the text as `[n]` where n is its position in Links plus one
+type HTMLLink struct {
+	Text string
+	URL  string
+	// Line and Column locate the `[n]` marker of the link in the rendered lines
+	Line   int
+	Column int
+}
+
+// HTMLDocument is the readable text version of a HTML document
+type HTMLDocument struct {
+	Lines []string
+	Links []*HTMLLink
+}
+
+type htmlWord struct {
+	text  string
+	links []int
+	// offsets of the link markers inside `text`
+	offsets []int
+}
+
+type htmlRenderer struct {
+	width    int
+	document *HTMLDocument
+	words    []htmlWord
+	glue     bool
+	prefixes []string
+	lists    []int
+	pre      int
+	link     *HTMLLink
+	linkText []string
+	blank    bool
+}
+
+// RenderHTML turns the HTML `source` into text wrapped to `width` columns:
+// headings, paragraphs, lists, quotes and preformatted blocks keep their layout, entities are decoded
+// and links are numbered and listed at the end of the document
+func RenderHTML(source string, width int) *HTMLDocument {
+	renderer := &htmlRenderer{width: imax(width, 10), document: &HTMLDocument{}, blank: true}
+	tokenizeHTML(source, renderer.text, renderer.tag)
+	renderer.flush()
+	renderer.footnotes()
+	return renderer.document
+}
+
+func (renderer *htmlRenderer) text(text string) {
+	text = html.UnescapeString(text)
+	if renderer.link != nil {
+		renderer.linkText = append(renderer.linkText, strings.Fields(text)...)
+	}
+	if renderer.pre > 0 {
+		renderer.preformatted(text)
+		return
+	}
+	if text != "" && strings.TrimLeft(text[:1], " \t\r\n") == "" {
+		renderer.glue = false
+	}
+	for _, field := range strings.Fields(text) {
+		if renderer.glue && len(renderer.words) > 0 {
+			last := &renderer.words[len(renderer.words)-1]
+			last.text += field
+		} else {
+			renderer.words = append(renderer.words, htmlWord{text: field})
+		}
+		renderer.glue = false
+	}
+	renderer.glue = text != "" && strings.TrimRight(text[len(text)-1:], " \t\r\n") != ""
+}
+
+func (renderer *htmlRenderer) tag(name string, closing bool, attributes map[string]string) {
+	switch name {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		renderer.block(true)
+		if !closing {
+			renderer.words = append(renderer.words, htmlWord{text: strings.Repeat("#", int(name[1]-'0'))})
+		}
+	case "p", "div", "table", "section", "article", "header", "footer", "nav", "dl", "form":
+		renderer.block(true)
+	case "tr", "dt", "dd", "title":
+		renderer.block(false)
+	case "br":
+		renderer.block(false)
+	case "hr":
+		renderer.block(true)
+		renderer.line(strings.Repeat("-", renderer.width))
+		renderer.blank = false
+	case "td", "th":
+		renderer.glue = false
+	case "ul", "ol":
+		renderer.block(len(renderer.lists) == 0)
+		if closing {
+			if len(renderer.lists) > 0 {
+				renderer.lists = renderer.lists[:len(renderer.lists)-1]
+				renderer.prefixes = renderer.prefixes[:len(renderer.prefixes)-1]
+			}
+		} else {
+			counter := -1
+			if name == "ol" {
+				counter = 0
+			}
+			renderer.lists = append(renderer.lists, counter)
+			renderer.prefixes = append(renderer.prefixes, "")
+		}
+	case "li":
+		renderer.block(false)
+		if !closing && len(renderer.lists) > 0 {
+			last := len(renderer.lists) - 1
+			bullet := "* "
+			if renderer.lists[last] >= 0 {
+				renderer.lists[last]++
+				bullet = fmt.Sprintf("%d. ", renderer.lists[last])
+			}
+			renderer.words = append(renderer.words, htmlWord{text: strings.TrimSpace(bullet)})
+		}
+	case "blockquote":
+		renderer.block(true)
+		if closing {
+			if len(renderer.prefixes) > 0 {
+				renderer.prefixes = renderer.prefixes[:len(renderer.prefixes)-1]
+			}
+		} else {
+			renderer.prefixes = append(renderer.prefixes, "> ")
+		}
+	case "pre":
+		if lines := renderer.document.Lines; closing && len(lines) > 0 && lines[len(lines)-1] == "" {
+			renderer.blank = true
+		}
+		renderer.block(true)
+		if closing {
+			renderer.pre = imax(renderer.pre-1, 0)
+		} else {
+			renderer.pre++
+		}
+	case "a":
+		if closing {
+			renderer.closeLink()
+		} else if href, ok := attributes["href"]; ok && href != "" {
+			renderer.closeLink()
+			renderer.link = &HTMLLink{URL: html.UnescapeString(href)}
+			renderer.linkText = nil
+		}
+	case "img":
+		if alt := attributes["alt"]; alt != "" && !closing {
+			renderer.text(fmt.Sprintf(" [%s] ", alt))
+		}
+	}
+}
+
+func (renderer *htmlRenderer) closeLink() {
+	link := renderer.link
+	if link == nil {
+		return
+	}
+	renderer.link = nil
+	link.Text = strings.Join(renderer.linkText, " ")
+	renderer.document.Links = append(renderer.document.Links, link)
+	index := len(renderer.document.Links) - 1
+	marker := fmt.Sprintf("[%d]", index+1)
+
+	if renderer.pre > 0 {
+		renderer.preformatted("")
+		last := len(renderer.document.Lines) - 1
+		link.Line, link.Column = last, len(renderer.document.Lines[last])
+		renderer.document.Lines[last] += marker
+		return
+	}
+	if len(renderer.words) == 0 {
+		renderer.words = append(renderer.words, htmlWord{})
+	}
+	last := &renderer.words[len(renderer.words)-1]
+	last.links = append(last.links, index)
+	last.offsets = append(last.offsets, len(last.text))
+	last.text += marker
+}
+
+// block ends the current paragraph, `spaced` separates it from the next one by an empty line
+func (renderer *htmlRenderer) block(spaced bool) {
+	renderer.flush()
+	if spaced && !renderer.blank {
+		renderer.line("")
+		renderer.blank = true
+	}
+}
+
+func (renderer *htmlRenderer) prefix() string {
+	prefix := ""
+	for _, part := range renderer.prefixes {
+		if part == "" {
+			part = "  "
+		}
+		prefix += part
+	}
+	return prefix
+}
+
+func (renderer *htmlRenderer) line(text string) {
+	renderer.document.Lines = append(renderer.document.Lines, text)
+}
+
+// flush wraps the words of the current paragraph, keeping track of where the link markers end up
+func (renderer *htmlRenderer) flush() {
+	words := renderer.words
+	renderer.words, renderer.glue = nil, false
+	if len(words) == 0 {
+		return
+	}
+	prefix := renderer.prefix()
+	current := prefix
+	for i, word := range words {
+		if i > 0 && StringWidth(current)+1+StringWidth(word.text) > renderer.width && strings.TrimSpace(current) != "" {
+			renderer.line(current)
+			current = prefix
+		}
+		if len(current) > len(prefix) {
+			current += " "
+		}
+		for j, index := range word.links {
+			link := renderer.document.Links[index]
+			link.Line, link.Column = len(renderer.document.Lines), len(current)+word.offsets[j]
+		}
+		current += word.text
+	}
+	renderer.line(current)
+	renderer.blank = false
+}
+
+// preformatted appends `text` as is, the first line continuing the last one of the block
+func (renderer *htmlRenderer) preformatted(text string) {
+	lines := strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n")
+	if len(renderer.document.Lines) == 0 || renderer.blank {
+		renderer.line("")
+		renderer.blank = false
+		if lines[0] == "" && len(lines) > 1 {
+			lines = lines[1:]
+		}
+	}
+	last := len(renderer.document.Lines) - 1
+	for i, line := range lines {
+		if i > 0 {
+			renderer.line("")
+			last++
+		}
+		renderer.document.Lines[last] += strings.Replace(line, "\t", "    ", -1)
+	}
+}
+
+func (renderer *htmlRenderer) footnotes() {
+	renderer.closeLink()
+	for len(renderer.document.Lines) > 0 && strings.TrimSpace(renderer.document.Lines[len(renderer.document.Lines)-1]) == "" {
+		renderer.document.Lines = renderer.document.Lines[:len(renderer.document.Lines)-1]
+	}
+	if len(renderer.document.Links) == 0 {
+		return
+	}
+	renderer.line("")
+	renderer.line("Links:")
+	for i, link := range renderer.document.Links {
+		renderer.line(fmt.Sprintf("[%d] %s", i+1, link.URL))
+	}
+}
+
+// tokenizeHTML calls `text` for each piece of text and `tag` for each tag of `source`,
+// comments, declarations and the content of scripts and styles are skipped
+func tokenizeHTML(source string, text func(string), tag func(string, bool, map[string]string)) {
+	for len(source) > 0 {
+		start := strings.Index(source, "<")
+		if start < 0 {
+			text(source)
+			return
+		}
+		if start > 0 {
+			text(source[:start])
+			source = source[start:]
+		}
+
+		if strings.HasPrefix(source, "<!--") {
+			end := strings.Index(source, "-->")
+			if end < 0 {
+				return
+			}
+			source = source[end+3:]
+			continue
+		}
+		if len(source) < 2 || !(isLetter(source[1]) || source[1] == '/' || source[1] == '!' || source[1] == '?') {
+			text("<")
+			source = source[1:]
+			continue
+		}
+
+		end := tagEnd(source)
+		if end < 0 {
+			return
+		}
+		raw := source[1:end]
+		source = source[end+1:]
+		if raw[0] == '!' || raw[0] == '?' {
+			continue
+		}
+		name, closing, attributes := parseTag(raw)
+		tag(name, closing, attributes)
+
+		if (name == "script" || name == "style") && !closing {
+			end := strings.Index(strings.ToLower(source), "</"+name)
+			if end < 0 {
+				return
+			}
+			source = source[end:]
+		}
+	}
+}
+
+func isLetter(char byte) bool {
+	return 'a' <= char && char <= 'z' || 'A' <= char && char <= 'Z'
+}
+
+func tagEnd(source string) int {
+	var quote byte
+	for i := 1; i < len(source); i++ {
+		switch {
+		case quote != 0:
+			if source[i] == quote {
+				quote = 0
+			}
+		case source[i] == '"' || source[i] == '\'':
+			quote = source[i]
+		case source[i] == '>':
+			return i
+		}
+	}
+	return -1
+}
+
+func parseTag(raw string) (string, bool, map[string]string) {
+	closing := strings.HasPrefix(raw, "/")
+	raw = strings.TrimSuffix(strings.TrimPrefix(raw, "/"), "/")
+	i := strings.IndexAny(raw, " \t\r\n")
+	if i < 0 {
+		return strings.ToLower(raw), closing, nil
+	}
+	name, rest := strings.ToLower(raw[:i]), raw[i:]
+
+	attributes := map[string]string{}
+	for {
+		rest = strings.TrimLeft(rest, " \t\r\n")
+		if rest == "" {
+			break
+		}
+		end := strings.IndexAny(rest, "= \t\r\n")
+		if end < 0 {
+			attributes[strings.ToLower(rest)] = ""
+			break
+		}
+		key := strings.ToLower(rest[:end])
+		rest = strings.TrimLeft(rest[end:], " \t\r\n")
+		if !strings.HasPrefix(rest, "=") {
+			attributes[key] = ""
+			continue
+		}
+		rest = strings.TrimLeft(rest[1:], " \t\r\n")
+		value := ""
+		if rest != "" && (rest[0] == '"' || rest[0] == '\'') {
+			if close := strings.IndexByte(rest[1:], rest[0]); close >= 0 {
+				value, rest = rest[1:close+1], rest[close+2:]
+			} else {
+				value, rest = rest[1:], ""
+			}
+		} else if space := strings.IndexAny(rest, " \t\r\n"); space >= 0 {
+			value, rest = rest[:space], rest[space:]
+		} else {
+			value, rest = rest, ""
+		}
+		attributes[key] = value
+	}
+	return name, closing, attributes
+}
+
+func imax(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
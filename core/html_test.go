@@ -0,0 +1,53 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderHTML(t *testing.T) {
+	cases := []struct {
+		input  string
+		width  int
+		output []string
+	}{
+		{"", 20, nil},
+		{"hello   <b>big</b>\n world", 20, []string{"hello big world"}},
+		{"<h2>Title</h2><p>First</p><p>Second</p>", 20, []string{"## Title", "", "First", "", "Second"}},
+		{"one two three four", 10, []string{"one two", "three four"}},
+		{"a<br>b<hr>c", 10, []string{"a", "b", "", "----------", "c"}},
+		{"<ul><li>one<li>two</ul><ol><li>a</li><li>b</li></ol>", 20, []string{"  * one", "  * two", "", "  1. a", "  2. b"}},
+		{"<blockquote>quoted text</blockquote>", 20, []string{"> quoted text"}},
+		{"<pre>\n  x = 1\n  y = 2\n</pre>after", 20, []string{"  x = 1", "  y = 2", "", "after"}},
+		{"Tom &amp; Jerry &lt;3 &eacute;t&#233;", 30, []string{"Tom & Jerry <3 été"}},
+		{"<!-- hidden --><script>var a = '<p>';</script><style>p {}</style>shown", 20, []string{"shown"}},
+		{"<!DOCTYPE html><title>Page</title>a < b", 20, []string{"Page", "a < b"}},
+		{"<img src=\"x.png\" alt=\"Logo\">", 20, []string{"[Logo]"}},
+	}
+	for _, test := range cases {
+		assert.Equal(t, test.output, RenderHTML(test.input, test.width).Lines, "Expected %q to be rendered", test.input)
+	}
+}
+
+func TestRenderHTMLLinks(t *testing.T) {
+	document := RenderHTML(`<p>See <a href="gopher://example.com/1/">the hole</a>, or <A HREF='https://example.com/?a=1&amp;b=2'>the web</A></p>`, 20)
+	assert.Equal(t, []string{
+		"See the hole[1], or",
+		"the web[2]",
+		"",
+		"Links:",
+		"[1] gopher://example.com/1/",
+		"[2] https://example.com/?a=1&b=2",
+	}, document.Lines)
+	assert.Equal(t, []*HTMLLink{
+		{Text: "the hole", URL: "gopher://example.com/1/", Line: 0, Column: 12},
+		{Text: "the web", URL: "https://example.com/?a=1&b=2", Line: 1, Column: 7},
+	}, document.Links)
+}
+
+func TestRenderHTMLPreformattedLinks(t *testing.T) {
+	document := RenderHTML("<pre>code <a href=\"a.html\">here</a>\nnext</pre>", 20)
+	assert.Equal(t, []string{"code here[1]", "next", "", "Links:", "[1] a.html"}, document.Lines)
+	assert.Equal(t, []*HTMLLink{{Text: "here", URL: "a.html", Line: 0, Column: 9}}, document.Links)
+}
@@ -0,0 +1,171 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const menuTerminator = "."
+
+// MenuOptions configures how a menu is parsed
+type MenuOptions struct {
+	// Host and Port of the server which sent the menu, used for items missing them
+	Host string
+	Port string
+	// TLS marks the menu as received over TLS, its items on the same server will be requested the same way
+	TLS bool
+	// Strict makes parsing fail on the first malformed line instead of reporting a warning
+	Strict bool
+}
+
+// MenuWarning describes a line of a menu which had to be fixed to be parsed
+type MenuWarning struct {
+	Line    int
+	Source  string
+	Message string
+}
+
+func (warning MenuWarning) String() string {
+	return fmt.Sprintf("line %d: %s", warning.Line, warning.Message)
+}
+
+// MenuReader parses a Gopher menu incrementally, tolerating the usual server mistakes
+type MenuReader struct {
+	reader   *bufio.Reader
+	options  MenuOptions
+	line     int
+	done     bool
+	finished bool
+	warnings []MenuWarning
+}
+
+// NewMenuReader creates a MenuReader reading from `reader`
+func NewMenuReader(reader io.Reader, options MenuOptions) *MenuReader {
+	return &MenuReader{reader: bufio.NewReader(reader), options: options}
+}
+
+// ParseMenu reads a whole menu from `reader`, returning its records and the warnings for the lines which had to be fixed
+func ParseMenu(reader io.Reader, options MenuOptions) ([]*Record, []MenuWarning, error) {
+	menu := NewMenuReader(reader, options)
+	records := []*Record{}
+	for {
+		record, err := menu.Read()
+		if err == io.EOF {
+			return records, menu.Warnings(), nil
+		}
+		if err != nil {
+			return records, menu.Warnings(), err
+		}
+		records = append(records, record)
+	}
+}
+
+// Warnings returns every warning found so far
+func (menu *MenuReader) Warnings() []MenuWarning {
+	return menu.warnings
+}
+
+// Read returns the next record of the menu, or io.EOF once the menu is complete
+func (menu *MenuReader) Read() (*Record, error) {
+	if menu.finished {
+		return nil, io.EOF
+	}
+	for !menu.done {
+		source, err := menu.reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("while reading line: %s", err)
+		}
+		if err == io.EOF {
+			menu.done = true
+			if source == "" {
+				break
+			}
+		}
+		menu.line++
+
+		source = strings.TrimSuffix(strings.TrimSuffix(source, "\n"), "\r")
+		if strings.TrimRight(source, " \t") == menuTerminator {
+			menu.done, menu.finished = true, true
+			return nil, io.EOF
+		}
+
+		record, warning := menu.parseLine(source)
+		if warning != "" {
+			if err := menu.warn(source, warning); err != nil {
+				return nil, err
+			}
+		}
+		if record != nil {
+			return record, nil
+		}
+	}
+	menu.finished = true
+	if err := menu.warn("", fmt.Sprintf("menu not terminated by `%s`", menuTerminator)); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func (menu *MenuReader) warn(source, message string) error {
+	warning := MenuWarning{Line: menu.line, Source: source, Message: message}
+	if menu.options.Strict {
+		return fmt.Errorf("invalid menu, %s", warning)
+	}
+	menu.warnings = append(menu.warnings, warning)
+	return nil
+}
+
+func (menu *MenuReader) parseLine(source string) (*Record, string) {
+	if strings.TrimSpace(source) == "" {
+		return menu.info(""), "empty line"
+	}
+
+	fields := strings.Split(source, "\t")
+	if fields[0] == "" {
+		return menu.info(strings.TrimSpace(source)), "missing item type, shown as text"
+	}
+	gtype := ParseEntry(fields[0][0])
+	if len(fields) == 1 && gtype != TypeInformational {
+		return menu.info(source), "not a menu item, shown as text"
+	}
+	if gtype == TypeInformational || gtype == TypeError {
+		record, _ := ParseRecord(source)
+		return record, ""
+	}
+
+	warning := ""
+	for len(fields) < 4 {
+		fields = append(fields, "")
+	}
+	selector, host, port := fields[1], strings.TrimSpace(fields[2]), strings.TrimSpace(fields[3])
+	if host == "" {
+		host, port = menu.options.Host, menu.options.Port
+		warning = "missing host, using the current server"
+	} else if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		if port == "" {
+			warning = "missing port"
+		} else {
+			warning = fmt.Sprintf("invalid port `%s`", port)
+		}
+		port = DefaultPort
+		if host == menu.options.Host && menu.options.Port != "" {
+			port = menu.options.Port
+		}
+		warning = fmt.Sprintf("%s, using %s", warning, port)
+	}
+
+	record, _ := ParseRecord(strings.Join(append([]string{fields[0], selector, host, port}, fields[4:]...), "\t"))
+	if menu.options.TLS && host == menu.options.Host && port == menu.options.Port {
+		address := URL{TLS: true, Host: host, Port: port, Type: record.Type, Selector: selector}
+		record.Address = address.String()
+	}
+	return record, warning
+}
+
+func (menu *MenuReader) info(display string) *Record {
+	record, _ := ParseRecord(string(TypeInformational) + display)
+	return record
+}
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var menuOptions = MenuOptions{Host: "example.com", Port: "7070"}
+
+func TestParseMenu(t *testing.T) {
+	source := "iWelcome\tfake\t(NULL)\t0\r\n1Menu\t/menu\texample.com\t70\r\n.\r\n"
+	records, warnings, err := ParseMenu(strings.NewReader(source), menuOptions)
+	assert.NoError(t, err)
+	assert.Empty(t, warnings)
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, "Welcome", records[0].ToString())
+		assert.Equal(t, "gopher://example.com/1/menu", records[1].Address)
+	}
+}
+
+func TestParseMenuLenient(t *testing.T) {
+	cases := []struct {
+		line    string
+		address string
+		display string
+		warning string
+	}{
+		{"1Bare LF\t/lf\texample.com\t70\n", "gopher://example.com/1/lf", "[menu] Bare LF", ""},
+		{"1Spaces\t/sp\texample.com\t70  \r\n", "gopher://example.com/1/sp", "[menu] Spaces", ""},
+		{"0No port\t/np\texample.com\r\n", "gopher://example.com:7070/0/np", "[file] No port", "line 1: missing port, using 7070"},
+		{"0Other host\t/oh\tother.org\t\r\n", "gopher://other.org/0/oh", "[file] Other host", "line 1: missing port, using 70"},
+		{"0Bad port\t/bp\tother.org\tabc\r\n", "gopher://other.org/0/bp", "[file] Bad port", "line 1: invalid port `abc`, using 70"},
+		{"1No host\t/nh\r\n", "gopher://example.com:7070/1/nh", "[menu] No host", "line 1: missing host, using the current server"},
+		{"Just some text\r\n", "", "Just some text", "line 1: not a menu item, shown as text"},
+		{"\r\n", "", "", "line 1: empty line"},
+		{"\tOops\r\n", "", "Oops", "line 1: missing item type, shown as text"},
+		{"iInfo without fields\r\n", "", "Info without fields", ""},
+	}
+	for _, test := range cases {
+		records, warnings, err := ParseMenu(strings.NewReader(test.line+".\r\n"), menuOptions)
+		assert.NoError(t, err)
+		if assert.Len(t, records, 1, "Unexpected records for %q", test.line) {
+			if test.address != "" {
+				assert.Equal(t, test.address, records[0].Address, "Unexpected address for %q", test.line)
+			}
+			assert.Equal(t, test.display, records[0].ToString(), "Unexpected display for %q", test.line)
+		}
+		if test.warning == "" {
+			assert.Empty(t, warnings, "Unexpected warnings for %q", test.line)
+		} else if assert.Len(t, warnings, 1, "Expected a warning for %q", test.line) {
+			assert.Equal(t, test.warning, warnings[0].String())
+		}
+	}
+}
+
+func TestParseMenuTerminator(t *testing.T) {
+	records, warnings, err := ParseMenu(strings.NewReader("1A\t/a\th\t70\r\n.hidden\t/b\th\t70\r\n. \r\n1Ignored\t/c\th\t70\r\n"), menuOptions)
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Empty(t, warnings)
+
+	records, warnings, err = ParseMenu(strings.NewReader("1A\t/a\th\t70\r\n1B\t/b\th\t70"), menuOptions)
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	if assert.Len(t, warnings, 1) {
+		assert.Equal(t, "line 2: menu not terminated by `.`", warnings[0].String())
+	}
+}
+
+func TestParseMenuStrict(t *testing.T) {
+	options := menuOptions
+	options.Strict = true
+	records, _, err := ParseMenu(strings.NewReader("1A\t/a\th\t70\r\n1B\t/b\r\n.\r\n"), options)
+	assert.Error(t, err)
+	assert.Len(t, records, 1)
+}
+
+func TestMenuReader(t *testing.T) {
+	menu := NewMenuReader(strings.NewReader("iA\r\n\r\niB\r\n.\r\n"), menuOptions)
+	for _, display := range []string{"A", "", "B"} {
+		record, err := menu.Read()
+		if assert.NoError(t, err) {
+			assert.Equal(t, display, record.Display)
+		}
+	}
+	_, err := menu.Read()
+	assert.Equal(t, io.EOF, err)
+	_, err = menu.Read()
+	assert.Equal(t, io.EOF, err)
+	assert.Len(t, menu.Warnings(), 1)
+}
+
+func TestParseMenuTLS(t *testing.T) {
+	options := menuOptions
+	options.TLS = true
+	records, _, err := ParseMenu(strings.NewReader("1Same\t/a\texample.com\t7070\r\n1Other\t/b\tother.org\t70\r\n.\r\n"), options)
+	assert.NoError(t, err)
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, "gophers://example.com:7070/1/a", records[0].Address)
+		assert.Equal(t, "gopher://other.org/1/b", records[1].Address)
+	}
+}
+
+func TestParseMenuPlus(t *testing.T) {
+	records, warnings, err := ParseMenu(strings.NewReader("0About\t/about\texample.com\t7070\t+\r\n1Form\t/form\t\t\t?\r\n.\r\n"), menuOptions)
+	assert.NoError(t, err)
+	assert.Len(t, warnings, 1)
+	if assert.Len(t, records, 2) {
+		assert.True(t, records[0].Plus)
+		assert.False(t, records[0].Ask)
+		assert.True(t, records[1].Plus)
+		assert.True(t, records[1].Ask)
+	}
+}
@@ -0,0 +1,253 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Gopher+ strings sent after the selector of an item
+const (
+	PlusAttributes          = "!"
+	PlusDirectoryAttributes = "$"
+	PlusData                = "+\t1"
+)
+
+// ReadPlusResponse consumes the header line of a Gopher+ response and returns a reader for its content
+// Errors sent by the server (`--1` and `--2` headers) are returned as such
+func ReadPlusResponse(reader *bufio.Reader) (io.Reader, error) {
+	header, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("while reading Gopher+ header: %s", err)
+	}
+	header = strings.TrimRight(header, "\r\n")
+	if len(header) < 2 || (header[0] != '+' && header[0] != '-') {
+		return nil, fmt.Errorf("invalid Gopher+ header `%s`", header)
+	}
+
+	length, err := strconv.ParseInt(header[1:], 10, 64)
+	if err != nil || length < -2 {
+		return nil, fmt.Errorf("invalid Gopher+ header `%s`", header)
+	}
+	if header[0] == '-' {
+		return nil, fmt.Errorf("server error: %s", readPlusError(reader))
+	}
+	if length >= 0 {
+		return io.LimitReader(reader, length), nil
+	}
+	return reader, nil
+}
+
+func readPlusError(reader *bufio.Reader) string {
+	lines := []string{}
+	for {
+		line, err := reader.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if line == menuTerminator || (err != nil && line == "") {
+			break
+		}
+		lines = append(lines, line)
+		if err != nil {
+			break
+		}
+	}
+	if len(lines) == 0 {
+		return "unknown error"
+	}
+	return strings.Join(lines, " ")
+}
+
+// FormatPlusData returns the data block holding `lines`, sent after a request with the PlusData string
+func FormatPlusData(lines []string) string {
+	var result bytes.Buffer
+	result.WriteString("+-1\r\n")
+	for _, line := range lines {
+		if strings.HasPrefix(line, menuTerminator) {
+			result.WriteString(menuTerminator)
+		}
+		result.WriteString(line + "\r\n")
+	}
+	result.WriteString(menuTerminator + "\r\n")
+	return result.String()
+}
+
+// AttributeBlock is one `+NAME: value` block of the Gopher+ attributes of an item
+// Lines are the lines following the first one, without their leading space
+type AttributeBlock struct {
+	Name  string
+	Value string
+	Lines []string
+}
+
+// Attributes are the Gopher+ attributes of an item, Info is parsed from its +INFO block
+type Attributes struct {
+	Info   *Record
+	Blocks []AttributeBlock
+}
+
+// ParseAttributes reads the attributes of one item (`!` request) or of all the items of a menu (`$` request)
+func ParseAttributes(reader io.Reader) ([]*Attributes, error) {
+	scanner := bufio.NewScanner(reader)
+	items := []*Attributes{}
+	var current *Attributes
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == menuTerminator {
+			break
+		}
+		if strings.HasPrefix(line, "+") {
+			block := AttributeBlock{Name: line[1:]}
+			if i := strings.Index(line, ":"); i >= 0 {
+				block.Name, block.Value = line[1:i], strings.TrimSpace(line[i+1:])
+			}
+			if block.Name == "INFO" || current == nil {
+				current = &Attributes{}
+				items = append(items, current)
+			}
+			if block.Name == "INFO" {
+				current.Info, _ = ParseRecord(block.Value)
+			}
+			current.Blocks = append(current.Blocks, block)
+		} else if current != nil && len(current.Blocks) > 0 {
+			block := &current.Blocks[len(current.Blocks)-1]
+			block.Lines = append(block.Lines, strings.TrimPrefix(line, " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("while reading attributes: %s", err)
+	}
+	return items, nil
+}
+
+// Block returns the block called `name`, or nil if the item doesn't have it
+func (attributes *Attributes) Block(name string) *AttributeBlock {
+	for i := range attributes.Blocks {
+		if attributes.Blocks[i].Name == name {
+			return &attributes.Blocks[i]
+		}
+	}
+	return nil
+}
+
+// View is one of the representations of a Gopher+ item listed in its +VIEWS block
+type View struct {
+	Type     string
+	Language string
+	Size     string
+}
+
+// Views returns the representations the item is available in
+func (attributes *Attributes) Views() []View {
+	block := attributes.Block("VIEWS")
+	if block == nil {
+		return nil
+	}
+	views := []View{}
+	for _, line := range block.Lines {
+		description, size := line, ""
+		if i := strings.Index(line, ":"); i >= 0 {
+			description, size = line[:i], strings.Trim(strings.TrimSpace(line[i+1:]), "<>")
+		}
+		fields := strings.Fields(description)
+		if len(fields) < 1 {
+			continue
+		}
+		view := View{Type: fields[0], Size: size}
+		if len(fields) > 1 {
+			view.Language = fields[1]
+		}
+		views = append(views, view)
+	}
+	return views
+}
+
+// Plus returns the Gopher+ string requesting the item in this view
+func (view View) Plus() string {
+	if view.Language != "" {
+		return "+" + view.Type + " " + view.Language
+	}
+	return "+" + view.Type
+}
+
+// Entry returns the Gopher type matching the content of the view
+func (view View) Entry() GopherEntry {
+	mime := strings.ToLower(view.Type)
+	switch {
+	case mime == "application/gopher-menu" || mime == "application/gopher+-menu":
+		return TypeSubMenu
+	case mime == "text/html":
+		return TypeHTML
+	case strings.HasPrefix(mime, "text/"):
+		return TypeFile
+	case mime == "image/gif":
+		return TypeGIF
+	case strings.HasPrefix(mime, "image/"):
+		return TypeImage
+	case strings.HasPrefix(mime, "audio/"):
+		return TypeSound
+	}
+	return TypeBinary
+}
+
+func (view View) String() string {
+	result := view.Type
+	if view.Language != "" {
+		result += " " + view.Language
+	}
+	if view.Size != "" {
+		result += " <" + view.Size + ">"
+	}
+	return result
+}
+
+// AskKind is the type of a question in a +ASK block
+type AskKind string
+
+// Kinds of questions which can be found in a +ASK block
+const (
+	AskText     AskKind = "Ask"
+	AskPassword AskKind = "AskP"
+	AskLong     AskKind = "AskL"
+	AskFile     AskKind = "AskF"
+	AskSelect   AskKind = "Select"
+	AskChoose   AskKind = "Choose"
+	AskNote     AskKind = "Note"
+)
+
+// AskField is one question of the +ASK block of an item
+// Choices holds the default answer of text questions, the options of AskChoose and the default state ("0" or "1") of AskSelect
+type AskField struct {
+	Kind    AskKind
+	Prompt  string
+	Choices []string
+}
+
+// Answerable returns if the question expects an answer (notes are only displayed)
+func (field AskField) Answerable() bool {
+	return field.Kind != AskNote
+}
+
+// Ask returns the questions of the +ASK block of the item
+func (attributes *Attributes) Ask() []AskField {
+	block := attributes.Block("ASK")
+	if block == nil {
+		return nil
+	}
+	fields := []AskField{}
+	for _, line := range block.Lines {
+		i := strings.Index(line, ":")
+		if i < 0 {
+			continue
+		}
+		parts := strings.Split(strings.TrimPrefix(line[i+1:], " "), "\t")
+		field := AskField{Kind: AskKind(strings.TrimSpace(line[:i])), Prompt: parts[0], Choices: parts[1:]}
+		switch field.Kind {
+		case AskText, AskPassword, AskLong, AskFile, AskSelect, AskChoose, AskNote:
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
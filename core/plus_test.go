@@ -0,0 +1,106 @@
+package core
+
+import (
+	"bufio"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadPlusResponse(t *testing.T) {
+	cases := []struct {
+		input  string
+		output string
+	}{
+		{"+-1\r\nhello\r\n.\r\n", "hello\r\n.\r\n"},
+		{"+-2\r\nhello", "hello"},
+		{"+5\r\nhello world", "hello"},
+	}
+	for _, test := range cases {
+		reader, err := ReadPlusResponse(bufio.NewReader(strings.NewReader(test.input)))
+		if assert.NoError(t, err, "Expected %q to be read", test.input) {
+			content, _ := ioutil.ReadAll(reader)
+			assert.Equal(t, test.output, string(content))
+		}
+	}
+}
+
+func TestFailReadPlusResponse(t *testing.T) {
+	cases := []struct {
+		input string
+		err   string
+	}{
+		{"", "invalid Gopher+ header ``"},
+		{"1Menu\t/\thost\t70\r\n", "invalid Gopher+ header `1Menu\t/\thost\t70`"},
+		{"+abc\r\n", "invalid Gopher+ header `+abc`"},
+		{"--1\r\n1 Item not found\r\n.\r\n", "server error: 1 Item not found"},
+	}
+	for _, test := range cases {
+		_, err := ReadPlusResponse(bufio.NewReader(strings.NewReader(test.input)))
+		if assert.Error(t, err, "Expected %q to fail", test.input) {
+			assert.Equal(t, test.err, err.Error())
+		}
+	}
+}
+
+func TestFormatPlusData(t *testing.T) {
+	assert.Equal(t, "+-1\r\nJohn\r\n..hidden\r\n.\r\n", FormatPlusData([]string{"John", ".hidden"}))
+}
+
+const attributes = "+INFO: 0About\t/about\texample.com\t70\t+\r\n" +
+	"+ADMIN:\r\n Admin: Jane <jane@example.com>\r\n Mod-Date: <19930406150000>\r\n" +
+	"+ABSTRACT:\r\n A short description\r\n" +
+	"+VIEWS:\r\n text/plain: <1k>\r\n application/postscript En_US: <10k>\r\n" +
+	"+INFO: 1Form\t/form\texample.com\t70\t?\r\n" +
+	"+ASK:\r\n Note: Please answer\r\n Ask: Name?\tJohn\r\n AskP: Password?\r\n Choose: Color?\tRed\tBlue\r\n Unknown: ignored\r\n" +
+	".\r\n"
+
+func TestParseAttributes(t *testing.T) {
+	items, err := ParseAttributes(strings.NewReader(attributes))
+	assert.NoError(t, err)
+	if !assert.Len(t, items, 2) {
+		return
+	}
+
+	about := items[0]
+	assert.Equal(t, "gopher://example.com/0/about", about.Info.Address)
+	assert.True(t, about.Info.Plus)
+	assert.Equal(t, []string{"Admin: Jane <jane@example.com>", "Mod-Date: <19930406150000>"}, about.Block("ADMIN").Lines)
+	assert.Equal(t, []string{"A short description"}, about.Block("ABSTRACT").Lines)
+	assert.Nil(t, about.Block("ASK"))
+	assert.Equal(t, []View{
+		{Type: "text/plain", Size: "1k"},
+		{Type: "application/postscript", Language: "En_US", Size: "10k"},
+	}, about.Views())
+
+	form := items[1]
+	assert.True(t, form.Info.Ask)
+	assert.Nil(t, form.Views())
+	assert.Equal(t, []AskField{
+		{Kind: AskNote, Prompt: "Please answer", Choices: []string{}},
+		{Kind: AskText, Prompt: "Name?", Choices: []string{"John"}},
+		{Kind: AskPassword, Prompt: "Password?", Choices: []string{}},
+		{Kind: AskChoose, Prompt: "Color?", Choices: []string{"Red", "Blue"}},
+	}, form.Ask())
+}
+
+func TestView(t *testing.T) {
+	cases := []struct {
+		view  View
+		plus  string
+		entry GopherEntry
+	}{
+		{View{Type: "text/plain"}, "+text/plain", TypeFile},
+		{View{Type: "application/gopher+-menu"}, "+application/gopher+-menu", TypeSubMenu},
+		{View{Type: "text/html", Language: "Fr_FR"}, "+text/html Fr_FR", TypeHTML},
+		{View{Type: "image/gif"}, "+image/gif", TypeGIF},
+		{View{Type: "image/jpeg"}, "+image/jpeg", TypeImage},
+		{View{Type: "application/zip"}, "+application/zip", TypeBinary},
+	}
+	for _, test := range cases {
+		assert.Equal(t, test.plus, test.view.Plus())
+		assert.Equal(t, test.entry, test.view.Entry())
+	}
+}
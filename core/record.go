@@ -31,14 +31,22 @@ const (
 )
 
 // Record represents one entry in a Gopher response
+// Plus is set for Gopher+ items, which have attributes and may have several views
+// Ask is set for Gopher+ items expecting the answers to a form (+ASK block) in their request
+// URL is the target of `h` items pointing outside of Gopher, written `URL:<target>` in their selector
 type Record struct {
 	Type    GopherEntry
 	Display string
 	Address string
 	Label   string
 	String  string
+	Plus    bool
+	Ask     bool
+	URL     string
 }
 
+const urlSelector = "URL:"
+
 // ParseEntry parses a byte into an entry type
 func ParseEntry(entry byte) GopherEntry {
 	return GopherEntry(entry)
@@ -67,14 +75,35 @@ func (record *Record) parse(source string) bool {
 		record.String = record.Display
 	}
 	if len(fields) >= 4 {
-		record.Address = fmt.Sprintf("gopher://%s:%s/?q=%s&t=%c", fields[2], fields[3], fields[1], record.Type)
+		address := URL{Host: fields[2], Port: fields[3], Type: record.Type, Selector: fields[1]}
+		record.Address = address.String()
+		if selector := strings.TrimPrefix(fields[1], "/"); record.Type == TypeHTML && strings.HasPrefix(selector, urlSelector) {
+			record.URL = strings.TrimSpace(selector[len(urlSelector):])
+		}
+	}
+	if len(fields) >= 5 && fields[4] != "" {
+		record.Plus = fields[4][0] == '+' || fields[4][0] == '?'
+		record.Ask = fields[4][0] == '?'
 	}
 	return true
 }
 
 // IsLink returns if the entry can be requested to a Gopher server
 func (record *Record) IsLink() bool {
-	return record.Type == TypeSubMenu || record.Type == TypeHTML
+	switch record.Type {
+	case TypeSubMenu, TypeHTML, TypeSearch, TypeFile:
+		return true
+	}
+	return record.IsBinary()
+}
+
+// IsBinary returns if the entry should be saved to disk instead of displayed
+func (record *Record) IsBinary() bool {
+	switch record.Type {
+	case TypeBinHex, TypeDOS, TypeUUEncoded, TypeBinary, TypeGIF, TypeImage, TypeSound:
+		return true
+	}
+	return false
 }
 
 // ToString returns a displayable representation of the Record
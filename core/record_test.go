@@ -24,6 +24,16 @@ func testLink(t *testing.T, record *Record, gtype string, shouldBe bool) {
 	assert.Equal(t, shouldBe, record.IsLink(), message, gtype)
 }
 
+func testBinary(t *testing.T, record *Record, gtype string, shouldBe bool) {
+	var message string
+	if shouldBe {
+		message = "Expected %s entry to be binary, but it wasn't."
+	} else {
+		message = "Expected %s entry not to be binary, but it was."
+	}
+	assert.Equal(t, shouldBe, record.IsBinary(), message, gtype)
+}
+
 func testString(t *testing.T, record *Record, gtype string, display string) {
 	assert.Equal(t, display, record.ToString(), "Expected %s entry to be displayed has %s, but it was %s", gtype, display, record.ToString())
 }
@@ -39,14 +49,50 @@ func TestCreateAddress(t *testing.T) {
 	param := "0123\t/req\tgo.server.net\t42"
 	record := initTest(t, gtype, param)
 
-	address := "gopher://go.server.net:42/?q=/req&t=0"
+	address := "gopher://go.server.net:42/0/req"
 	assert.Equal(t, address, record.Address, "Expected address for %q to be %q, but it was %q instead.", param, address, record.Address)
 }
 
+func TestGopherPlus(t *testing.T) {
+	cases := []struct {
+		param string
+		plus  bool
+		ask   bool
+	}{
+		{"0123\t/req\thost\t70", false, false},
+		{"0123\t/req\thost\t70\t", false, false},
+		{"0123\t/req\thost\t70\t+", true, false},
+		{"1123\t/form\thost\t70\t?", true, true},
+	}
+	for _, test := range cases {
+		record := initTest(t, "Gopher+", test.param)
+		assert.Equal(t, test.plus, record.Plus, "Unexpected Gopher+ flag for %q", test.param)
+		assert.Equal(t, test.ask, record.Ask, "Unexpected ASK flag for %q", test.param)
+	}
+}
+
+func TestURLSelector(t *testing.T) {
+	cases := []struct {
+		param string
+		url   string
+	}{
+		{"hWeb\tURL:https://example.org/\thost\t70", "https://example.org/"},
+		{"hWeb\t/URL:http://example.org/a b\thost\t70", "http://example.org/a b"},
+		{"hPage\t/page.html\thost\t70", ""},
+		{"0Text\tURL:https://example.org/\thost\t70", ""},
+		{"hWeb", ""},
+	}
+	for _, test := range cases {
+		record := initTest(t, "HTML", test.param)
+		assert.Equal(t, test.url, record.URL, "Unexpected URL for %q", test.param)
+	}
+}
+
 func TestFile(t *testing.T) {
 	gtype := "File"
 	record := initTest(t, gtype, "0123")
-	testLink(t, record, gtype, false)
+	testLink(t, record, gtype, true)
+	testBinary(t, record, gtype, false)
 	testString(t, record, gtype, "[file] 123")
 }
 
@@ -54,6 +100,7 @@ func TestSubMenu(t *testing.T) {
 	gtype := "Sub Menu"
 	record := initTest(t, gtype, "1123")
 	testLink(t, record, gtype, true)
+	testBinary(t, record, gtype, false)
 	testString(t, record, gtype, "[menu] 123")
 }
 
@@ -74,28 +121,32 @@ func TestError(t *testing.T) {
 func TestBinHex(t *testing.T) {
 	gtype := "BinHex"
 	record := initTest(t, gtype, "4123")
-	testLink(t, record, gtype, false)
+	testLink(t, record, gtype, true)
+	testBinary(t, record, gtype, true)
 	testString(t, record, gtype, "[binary] 123")
 }
 
 func TestDOS(t *testing.T) {
 	gtype := "DOS"
 	record := initTest(t, gtype, "5123")
-	testLink(t, record, gtype, false)
+	testLink(t, record, gtype, true)
+	testBinary(t, record, gtype, true)
 	testString(t, record, gtype, "[binary] 123")
 }
 
 func TestUUEncoded(t *testing.T) {
 	gtype := "UUEncoded"
 	record := initTest(t, gtype, "6123")
-	testLink(t, record, gtype, false)
+	testLink(t, record, gtype, true)
+	testBinary(t, record, gtype, true)
 	testString(t, record, gtype, "[binary] 123")
 }
 
 func TestSearch(t *testing.T) {
 	gtype := "Search"
 	record := initTest(t, gtype, "7123")
-	testLink(t, record, gtype, false)
+	testLink(t, record, gtype, true)
+	testBinary(t, record, gtype, false)
 	testString(t, record, gtype, "[search] 123")
 }
 
@@ -109,7 +160,8 @@ func TestTelnet(t *testing.T) {
 func TestBinary(t *testing.T) {
 	gtype := "Binary"
 	record := initTest(t, gtype, "9123")
-	testLink(t, record, gtype, false)
+	testLink(t, record, gtype, true)
+	testBinary(t, record, gtype, true)
 	testString(t, record, gtype, "[binary] 123")
 }
 
@@ -130,14 +182,16 @@ func TestTelnet3270(t *testing.T) {
 func TestGIF(t *testing.T) {
 	gtype := "GIF"
 	record := initTest(t, gtype, "g123")
-	testLink(t, record, gtype, false)
+	testLink(t, record, gtype, true)
+	testBinary(t, record, gtype, true)
 	testString(t, record, gtype, "[image] 123")
 }
 
 func TestImage(t *testing.T) {
 	gtype := "Image"
 	record := initTest(t, gtype, "I123")
-	testLink(t, record, gtype, false)
+	testLink(t, record, gtype, true)
+	testBinary(t, record, gtype, true)
 	testString(t, record, gtype, "[image] 123")
 }
 
@@ -145,6 +199,7 @@ func TestHTML(t *testing.T) {
 	gtype := "HTML"
 	record := initTest(t, gtype, "h123")
 	testLink(t, record, gtype, true)
+	testBinary(t, record, gtype, false)
 	testString(t, record, gtype, "[html] 123")
 }
 
@@ -152,12 +207,14 @@ func TestInfo(t *testing.T) {
 	gtype := "Informational"
 	record := initTest(t, gtype, "i123")
 	testLink(t, record, gtype, false)
+	testBinary(t, record, gtype, false)
 	testString(t, record, gtype, "123")
 }
 
 func TestSound(t *testing.T) {
 	gtype := "Sound"
 	record := initTest(t, gtype, "s123")
-	testLink(t, record, gtype, false)
+	testLink(t, record, gtype, true)
+	testBinary(t, record, gtype, true)
 	testString(t, record, gtype, "[sound] 123")
 }
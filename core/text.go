@@ -0,0 +1,25 @@
+package core
+
+import (
+	"unicode"
+
+	runewidth "github.com/mattn/go-runewidth"
+)
+
+// RuneWidth returns how many columns `char` takes on a terminal: 2 for East Asian wide characters,
+// 0 for combining marks and other zero-width characters (which are drawn over the previous one)
+func RuneWidth(char rune) int {
+	if unicode.In(char, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	return runewidth.RuneWidth(char)
+}
+
+// StringWidth returns how many columns `text` takes on a terminal
+func StringWidth(text string) int {
+	width := 0
+	for _, char := range text {
+		width += RuneWidth(char)
+	}
+	return width
+}
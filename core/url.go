@@ -0,0 +1,184 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// DefaultPort is the port used when a Gopher URL doesn't specify one
+const DefaultPort = "70"
+
+const scheme, secureScheme = "gopher", "gophers"
+
+// URL represents the location of a Gopher item as described by RFC 4266
+// TLS is set for `gophers://` URLs, which must be requested over an encrypted connection
+// Plus is the Gopher+ string sent after the selector and search (e.g. `!` for the attributes of the item)
+type URL struct {
+	TLS      bool
+	Host     string
+	Port     string
+	Type     GopherEntry
+	Selector string
+	Search   string
+	Plus     string
+}
+
+// ParseURL initializes a URL by parsing the provided `source`, or fail
+// Both the standard `gopher://host:port/<type><selector>%09<search>` form
+// and the legacy `gopher://host:port/?q=<selector>&t=<type>` form are accepted
+func ParseURL(source string) (*URL, error) {
+	address := URL{}
+	if err := address.parse(source); err != nil {
+		return nil, fmt.Errorf("invalid url `%s`: %s", source, err)
+	}
+	return &address, nil
+}
+
+func (address *URL) parse(source string) error {
+	i := strings.Index(source, "://")
+	if i < 0 {
+		return fmt.Errorf("missing `%s://` scheme", scheme)
+	}
+	switch strings.ToLower(source[:i]) {
+	case scheme:
+	case secureScheme:
+		address.TLS = true
+	default:
+		return fmt.Errorf("unsupported scheme `%s`", source[:i])
+	}
+	rest := source[i+3:]
+
+	authority, path := rest, ""
+	if i := strings.Index(rest, "/"); i >= 0 {
+		authority, path = rest[:i], rest[i+1:]
+	}
+	if err := address.parseAuthority(authority); err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(path, "?") {
+		return address.parseLegacy(path[1:])
+	}
+
+	address.Type = TypeSubMenu
+	if path == "" {
+		return nil
+	}
+	address.Type = ParseEntry(path[0])
+	fields := strings.SplitN(unescape(path[1:]), "\t", 3)
+	address.Selector = fields[0]
+	if len(fields) > 1 {
+		address.Search = fields[1]
+	}
+	if len(fields) > 2 {
+		address.Plus = fields[2]
+	}
+	return nil
+}
+
+func (address *URL) parseAuthority(authority string) error {
+	host, port := authority, ""
+	if strings.LastIndex(authority, ":") > strings.LastIndex(authority, "]") {
+		var err error
+		host, port, err = net.SplitHostPort(authority)
+		if err != nil {
+			return err
+		}
+	}
+	address.Host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	address.Port = port
+	if address.Port == "" {
+		address.Port = DefaultPort
+	}
+	if address.Host == "" {
+		return fmt.Errorf("missing host")
+	}
+	return nil
+}
+
+func (address *URL) parseLegacy(query string) error {
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return err
+	}
+	address.Type = TypeSubMenu
+	if t := values.Get("t"); t != "" {
+		address.Type = ParseEntry(t[0])
+	}
+	address.Selector = values.Get("q")
+	address.Search = values.Get("s")
+	return nil
+}
+
+// HostPort returns the `host:port` pair to connect to
+func (address *URL) HostPort() string {
+	return net.JoinHostPort(address.Host, address.Port)
+}
+
+// Request returns the line to send to the server (without the final CRLF)
+func (address *URL) Request() string {
+	request := address.Selector
+	if address.Search != "" {
+		request += "\t" + address.Search
+	}
+	if address.Plus != "" {
+		request += "\t" + address.Plus
+	}
+	return request
+}
+
+// String returns the standard representation of the URL
+func (address *URL) String() string {
+	host := address.Host
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if address.Port != "" && address.Port != DefaultPort {
+		host = net.JoinHostPort(address.Host, address.Port)
+	}
+	gtype := address.Type
+	if gtype == 0 {
+		gtype = TypeSubMenu
+	}
+	prefix := scheme
+	if address.TLS {
+		prefix = secureScheme
+	}
+	path := address.Request()
+	if address.Plus != "" {
+		// The search must stay in the URL, even empty, so the Gopher+ string isn't mistaken for it
+		path = address.Selector + "\t" + address.Search + "\t" + address.Plus
+	}
+	return fmt.Sprintf("%s://%s/%c%s", prefix, host, gtype, escape(path))
+}
+
+func escape(source string) string {
+	var result bytes.Buffer
+	for i := 0; i < len(source); i++ {
+		char := source[i]
+		if shouldEscape(char) {
+			fmt.Fprintf(&result, "%%%02X", char)
+		} else {
+			result.WriteByte(char)
+		}
+	}
+	return result.String()
+}
+
+func shouldEscape(char byte) bool {
+	if 'a' <= char && char <= 'z' || 'A' <= char && char <= 'Z' || '0' <= char && char <= '9' {
+		return false
+	}
+	return !strings.ContainsRune("-_.~/:@!$&'()*+,;=", rune(char))
+}
+
+func unescape(source string) string {
+	result, err := url.PathUnescape(source)
+	if err != nil {
+		return source
+	}
+	return result
+}
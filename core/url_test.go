@@ -0,0 +1,92 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseURL(t *testing.T) {
+	cases := []struct {
+		input  string
+		output URL
+	}{
+		{"gopher://example.com", URL{Host: "example.com", Port: "70", Type: TypeSubMenu}},
+		{"gopher://example.com/", URL{Host: "example.com", Port: "70", Type: TypeSubMenu}},
+		{"gopher://example.com:7070/1/users", URL{Host: "example.com", Port: "7070", Type: TypeSubMenu, Selector: "/users"}},
+		{"gopher://example.com/0/a%20file.txt", URL{Host: "example.com", Port: "70", Type: TypeFile, Selector: "/a file.txt"}},
+		{"gopher://example.com/7/search%09query%20words", URL{Host: "example.com", Port: "70", Type: TypeSearch, Selector: "/search", Search: "query words"}},
+		{"gopher://[::1]:70/1", URL{Host: "::1", Port: "70", Type: TypeSubMenu}},
+		{"gopher://[::1]/1", URL{Host: "::1", Port: "70", Type: TypeSubMenu}},
+		{"GOPHER://example.com/h/100%", URL{Host: "example.com", Port: "70", Type: TypeHTML, Selector: "/100%"}},
+		{"gopher://example.com:42/?q=/req&t=0", URL{Host: "example.com", Port: "42", Type: TypeFile, Selector: "/req"}},
+		{"gopher://example.com:42/?q=/s&t=7&s=hello", URL{Host: "example.com", Port: "42", Type: TypeSearch, Selector: "/s", Search: "hello"}},
+		{"gophers://example.com/0/secret", URL{TLS: true, Host: "example.com", Port: "70", Type: TypeFile, Selector: "/secret"}},
+		{"gopher://example.com/0/about%09%09!", URL{Host: "example.com", Port: "70", Type: TypeFile, Selector: "/about", Plus: "!"}},
+		{"gopher://example.com/1/form%09%09+%091", URL{Host: "example.com", Port: "70", Type: TypeSubMenu, Selector: "/form", Plus: "+\t1"}},
+	}
+	for _, test := range cases {
+		address, err := ParseURL(test.input)
+		if assert.NoError(t, err, "Expected %q to be parsed", test.input) {
+			assert.Equal(t, test.output, *address, "Unexpected result for %q", test.input)
+		}
+	}
+}
+
+func TestFailParseURL(t *testing.T) {
+	cases := []string{"", "example.com", "http://example.com/", "gopherss://example.com/", "gopher://", "gopher:///1/selector", "gopher://host:port:42/"}
+	for _, test := range cases {
+		_, err := ParseURL(test)
+		assert.Error(t, err, "Expected parsing to fail for %q, but it succeeded.", test)
+	}
+}
+
+func TestURLString(t *testing.T) {
+	cases := []struct {
+		input  URL
+		output string
+	}{
+		{URL{Host: "example.com", Port: "70", Type: TypeSubMenu}, "gopher://example.com/1"},
+		{URL{Host: "example.com", Type: TypeSubMenu}, "gopher://example.com/1"},
+		{URL{Host: "example.com", Port: "7070", Type: TypeFile, Selector: "/a file?.txt"}, "gopher://example.com:7070/0/a%20file%3F.txt"},
+		{URL{Host: "example.com", Port: "70", Type: TypeSearch, Selector: "/s", Search: "hello world"}, "gopher://example.com/7/s%09hello%20world"},
+		{URL{Host: "::1", Port: "70", Type: TypeSubMenu, Selector: "/"}, "gopher://[::1]/1/"},
+		{URL{Host: "::1", Port: "7070", Type: TypeSubMenu}, "gopher://[::1]:7070/1"},
+		{URL{TLS: true, Host: "example.com", Port: "7443", Type: TypeSubMenu}, "gophers://example.com:7443/1"},
+		{URL{Host: "example.com", Type: TypeFile, Selector: "/a", Plus: "+text/plain"}, "gopher://example.com/0/a%09%09+text/plain"},
+		{URL{Host: "example.com", Type: TypeSearch, Selector: "/s", Search: "q", Plus: "$"}, "gopher://example.com/7/s%09q%09$"},
+	}
+	for _, test := range cases {
+		assert.Equal(t, test.output, test.input.String())
+	}
+}
+
+func TestURLRoundTrip(t *testing.T) {
+	cases := []string{
+		"gopher://example.com/1",
+		"gopher://example.com:7070/0/caf%C3%A9%23menu",
+		"gopher://example.com/7/search%09a%25b",
+		"gopher://example.com/1/dir%09%09$",
+	}
+	for _, test := range cases {
+		address, err := ParseURL(test)
+		if assert.NoError(t, err) {
+			assert.Equal(t, test, address.String())
+		}
+	}
+}
+
+func TestURLRequest(t *testing.T) {
+	cases := []struct {
+		input  URL
+		output string
+	}{
+		{URL{Selector: "/a"}, "/a"},
+		{URL{Selector: "/s", Search: "q"}, "/s\tq"},
+		{URL{Selector: "/a", Plus: "!"}, "/a\t!"},
+		{URL{Selector: "/s", Search: "q", Plus: "$"}, "/s\tq\t$"},
+	}
+	for _, test := range cases {
+		assert.Equal(t, test.output, test.input.Request())
+	}
+}
@@ -0,0 +1,60 @@
+package taupe
+
+import (
+	"regexp"
+)
+
+// findMatch is where a search matched in the lines of a page, `start` and `end` are byte offsets in its line
+type findMatch struct {
+	line, start, end int
+}
+
+func (match findMatch) before(other findMatch) bool {
+	return match.line < other.line || (match.line == other.line && match.start < other.start)
+}
+
+// findPattern compiles the query of an in-page search, which is plain text unless `regex` is set
+func findPattern(query string, regex, ignoreCase bool) (*regexp.Regexp, error) {
+	if !regex {
+		query = regexp.QuoteMeta(query)
+	}
+	if ignoreCase {
+		query = "(?i)" + query
+	}
+	return regexp.Compile(query)
+}
+
+// findMatches returns the matches of `pattern` in `lines` in order, empty matches are ignored
+func findMatches(lines []string, pattern *regexp.Regexp) []findMatch {
+	matches := []findMatch{}
+	for i, line := range lines {
+		for _, found := range pattern.FindAllStringIndex(line, -1) {
+			if found[0] < found[1] {
+				matches = append(matches, findMatch{line: i, start: found[0], end: found[1]})
+			}
+		}
+	}
+	return matches
+}
+
+// nextMatch returns the index of the first match after `from` (before it when `backward` is set), which is included if `inclusive` is set,
+// the search wraps around the page and returns -1 when there are no matches
+func nextMatch(matches []findMatch, from findMatch, backward, inclusive bool) int {
+	if len(matches) == 0 {
+		return -1
+	}
+	if backward {
+		for i := len(matches) - 1; i >= 0; i-- {
+			if matches[i].before(from) || (inclusive && !from.before(matches[i])) {
+				return i
+			}
+		}
+		return len(matches) - 1
+	}
+	for i, match := range matches {
+		if from.before(match) || (inclusive && !match.before(from)) {
+			return i
+		}
+	}
+	return 0
+}
@@ -0,0 +1,57 @@
+package taupe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindPattern(t *testing.T) {
+	cases := []struct {
+		query      string
+		regex      bool
+		ignoreCase bool
+		line       string
+		output     []findMatch
+	}{
+		{"go", false, false, "Go go gopher", []findMatch{{0, 3, 5}, {0, 6, 8}}},
+		{"go", false, true, "Go go", []findMatch{{0, 0, 2}, {0, 3, 5}}},
+		{"a.c", false, false, "abc a.c", []findMatch{{0, 4, 7}}},
+		{"a.c", true, false, "abc a.c", []findMatch{{0, 0, 3}, {0, 4, 7}}},
+		{"x*", true, false, "axxb", []findMatch{{0, 1, 3}}},
+		{"é", false, true, "CAFÉ café", []findMatch{{0, 3, 5}, {0, 9, 11}}},
+	}
+	for _, test := range cases {
+		pattern, err := findPattern(test.query, test.regex, test.ignoreCase)
+		if assert.NoError(t, err, "Expected %q to compile", test.query) {
+			assert.Equal(t, test.output, findMatches([]string{test.line}, pattern), "Expected matches of %q in %q", test.query, test.line)
+		}
+	}
+
+	_, err := findPattern("a(", true, false)
+	assert.Error(t, err)
+	_, err = findPattern("a(", false, false)
+	assert.NoError(t, err)
+}
+
+func TestNextMatch(t *testing.T) {
+	matches := []findMatch{{1, 0, 2}, {1, 5, 7}, {4, 2, 4}}
+	cases := []struct {
+		from      findMatch
+		backward  bool
+		inclusive bool
+		output    int
+	}{
+		{findMatch{line: 0}, false, true, 0},
+		{findMatch{line: 1}, false, true, 0},
+		{findMatch{line: 1}, false, false, 1},
+		{matches[2], false, false, 0},
+		{findMatch{line: 4}, true, false, 1},
+		{matches[0], true, false, 2},
+		{matches[1], true, true, 1},
+	}
+	for _, test := range cases {
+		assert.Equal(t, test.output, nextMatch(matches, test.from, test.backward, test.inclusive), "Expected next match from %v", test.from)
+	}
+	assert.Equal(t, -1, nextMatch(nil, findMatch{}, false, true))
+}
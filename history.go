@@ -0,0 +1,118 @@
+package taupe
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// HistoryAddress is the address of the history page, searches are at `HistoryAddress/<query>`
+const HistoryAddress = "about:history"
+
+// Visit is one page loaded by the user
+type Visit struct {
+	Address string    `json:"address"`
+	Title   string    `json:"title"`
+	Type    string    `json:"type"`
+	Time    time.Time `json:"time"`
+}
+
+// SessionEntry is a page of the session with the line which was selected on it
+type SessionEntry struct {
+	Address string `json:"address"`
+	Line    int    `json:"line"`
+}
+
+// Session is the state of the browser when it was last used, `Before` and `After` are the back/forward stacks
+type Session struct {
+	SessionEntry
+	Before []SessionEntry `json:"before"`
+	After  []SessionEntry `json:"after"`
+}
+
+// History is the log of the visited pages (the most recent last) and the last session, kept in a JSON file
+type History struct {
+	path    string
+	limit   int
+	Visits  []Visit `json:"visits"`
+	Session Session `json:"session"`
+}
+
+// HistoryPath returns the default location of the history file
+func HistoryPath() string {
+	return filepath.Join(ConfigDir(), "history.json")
+}
+
+// LoadHistory reads the history saved at `path`, keeping at most `limit` visits, a missing file is not an error
+func LoadHistory(path string, limit int) (*History, error) {
+	history := &History{path: path, limit: limit, Visits: []Visit{}}
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return history, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read history `%s`: %s", path, err)
+	}
+	if err = json.Unmarshal(content, history); err != nil {
+		return nil, fmt.Errorf("invalid history `%s`: %s", path, err)
+	}
+	history.trim()
+	return history, nil
+}
+
+// Save writes the history back to its file
+func (history *History) Save() error {
+	content, err := json.Marshal(history)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(history.path), 0755); err != nil {
+		return fmt.Errorf("cannot create directory for `%s`: %s", history.path, err)
+	}
+	if err = ioutil.WriteFile(history.path, content, 0600); err != nil {
+		return fmt.Errorf("cannot save history `%s`: %s", history.path, err)
+	}
+	return nil
+}
+
+// Add logs a new visit, dropping the oldest ones once the limit is reached (a limit of zero or less keeps all of them)
+func (history *History) Add(visit Visit) {
+	history.Visits = append(history.Visits, visit)
+	history.trim()
+}
+
+func (history *History) trim() {
+	if history.limit <= 0 {
+		return
+	}
+	if excess := len(history.Visits) - history.limit; excess > 0 {
+		history.Visits = append([]Visit{}, history.Visits[excess:]...)
+	}
+}
+
+// Search returns the visits whose address or title contain `query` (ignoring case), the most recent first
+func (history *History) Search(query string) []Visit {
+	query = strings.ToLower(query)
+	result := []Visit{}
+	for i := len(history.Visits) - 1; i >= 0; i-- {
+		visit := history.Visits[i]
+		if strings.Contains(strings.ToLower(visit.Address), query) || strings.Contains(strings.ToLower(visit.Title), query) {
+			result = append(result, visit)
+		}
+	}
+	return result
+}
+
+// Title returns the title of the most recent visit of `address`, or an empty string if it was never visited
+func (history *History) Title(address string) string {
+	for i := len(history.Visits) - 1; i >= 0; i-- {
+		if history.Visits[i].Address == address {
+			return history.Visits[i].Title
+		}
+	}
+	return ""
+}
@@ -0,0 +1,71 @@
+package taupe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/LouisBrunner/taupe/core"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testHistory(limit int) *History {
+	history := &History{limit: limit, Visits: []Visit{}}
+	history.Add(Visit{Address: "gopher://sdf.org/1", Title: "SDF", Type: "1", Time: time.Unix(1, 0)})
+	history.Add(Visit{Address: "gopher://gopher.floodgap.com/1", Title: "Floodgap", Type: "1", Time: time.Unix(2, 0)})
+	history.Add(Visit{Address: "gopher://sdf.org/0/about", Title: "About SDF", Type: "0", Time: time.Unix(3, 0)})
+	return history
+}
+
+func TestHistoryLimit(t *testing.T) {
+	history := testHistory(2)
+	if assert.Len(t, history.Visits, 2) {
+		assert.Equal(t, "Floodgap", history.Visits[0].Title)
+		assert.Equal(t, "About SDF", history.Visits[1].Title)
+	}
+	assert.Len(t, testHistory(0).Visits, 3)
+	assert.Len(t, testHistory(-1).Visits, 3)
+}
+
+func TestHistorySearch(t *testing.T) {
+	history := testHistory(10)
+	titles := func(visits []Visit) []string {
+		result := []string{}
+		for _, visit := range visits {
+			result = append(result, visit.Title)
+		}
+		return result
+	}
+	assert.Equal(t, []string{"About SDF", "Floodgap", "SDF"}, titles(history.Search("")))
+	assert.Equal(t, []string{"About SDF", "SDF"}, titles(history.Search("sdf")))
+	assert.Equal(t, []string{"Floodgap"}, titles(history.Search("FLOOD")))
+	assert.Equal(t, []string{}, titles(history.Search("nothing")))
+}
+
+func TestHistoryTitle(t *testing.T) {
+	history := testHistory(10)
+	assert.Equal(t, "SDF", history.Title("gopher://sdf.org/1"))
+	assert.Equal(t, "", history.Title("gopher://sdf.org/1/unknown"))
+}
+
+func TestVisitType(t *testing.T) {
+	cases := []struct {
+		input  string
+		output core.GopherEntry
+	}{
+		{"gopher://sdf.org/0/about", core.TypeFile},
+		{"gopher://sdf.org/7/search", core.TypeSearch},
+		{"gopher://sdf.org/7/search%09query", core.TypeSubMenu},
+		{"about:bookmarks", core.TypeSubMenu},
+	}
+	for _, test := range cases {
+		assert.Equal(t, test.output, visitType(test.input))
+	}
+}
+
+func TestHistorySearchAddress(t *testing.T) {
+	cases := []string{"", "sdf", "a/b c"}
+	for _, test := range cases {
+		assert.Equal(t, test, historyQuery(historySearchAddress(test)))
+	}
+}
@@ -0,0 +1,363 @@
+package taupe
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell"
+)
+
+// KeyActions are the actions which can be bound to keys, when several of them share a key the first one which applies wins
+var KeyActions = []string{
+	"find-next", "find-previous",
+	"search-history", "rename-bookmark", "delete-bookmark", "move-bookmark", "create-folder", "export-bookmarks", "import-bookmarks",
+	"cancel", "cancel-downloads",
+	"up", "down", "scroll-up", "scroll-down", "page-up", "page-down", "top", "bottom", "open", "find", "find-backward",
+	"link-number", "link-numbers", "hints",
+	"back", "forward", "refresh", "input",
+	"bookmark", "bookmarks", "history", "attributes", "views", "diagnostics", "help", "command",
+	"open-tab", "new-tab", "close-tab", "next-tab", "previous-tab",
+	"quit",
+}
+
+var defaultBindings = map[string][]string{
+	"search-history":   {"s", "S"},
+	"rename-bookmark":  {"n", "N"},
+	"delete-bookmark":  {"x", "X"},
+	"move-bookmark":    {"w", "W"},
+	"create-folder":    {"c", "C"},
+	"export-bookmarks": {"e", "E"},
+	"import-bookmarks": {"o", "O"},
+	"cancel":           {"Esc", "Ctrl+G"},
+	"cancel-downloads": {"Ctrl+G"},
+	"up":               {"Up"},
+	"down":             {"Down"},
+	"scroll-up":        {"Alt+Up"},
+	"scroll-down":      {"Alt+Down"},
+	"page-up":          {"PgUp"},
+	"page-down":        {"PgDn"},
+	"top":              {"Home"},
+	"bottom":           {"End"},
+	"open":             {"Enter"},
+	"find":             {"/"},
+	"find-backward":    {"?"},
+	"find-next":        {"n"},
+	"find-previous":    {"N"},
+	"link-number":      {"1", "2", "3", "4", "5", "6", "7", "8", "9"},
+	"link-numbers":     {"#"},
+	"hints":            {";"},
+	"back":             {"b", "B", "Backspace"},
+	"forward":          {"f", "F"},
+	"refresh":          {"r", "R"},
+	"input":            {"i", "I"},
+	"bookmark":         {"m", "M"},
+	"bookmarks":        {"l", "L"},
+	"history":          {"h", "H"},
+	"attributes":       {"a", "A"},
+	"views":            {"v", "V"},
+	"diagnostics":      {"d", "D"},
+	"help":             {"k", "K", "F1"},
+	"command":          {":"},
+	"open-tab":         {"t", "T"},
+	"new-tab":          {"Ctrl+T"},
+	"close-tab":        {"Ctrl+W"},
+	"next-tab":         {"Tab"},
+	"previous-tab":     {"Shift+Tab"},
+	"quit":             {"q", "Q", "Ctrl+C"},
+}
+
+// keyPresets change some of the default bindings
+var keyPresets = map[string]map[string][]string{
+	"default": {},
+	"vi": {
+		"import-bookmarks": {"O"},
+		"delete-bookmark":  {"X"},
+		"up":               {"k", "Up"},
+		"down":             {"j", "Down"},
+		"scroll-up":        {"Ctrl+Y", "Alt+Up"},
+		"scroll-down":      {"Ctrl+E", "Alt+Down"},
+		"page-up":          {"u", "Ctrl+B", "PgUp"},
+		"page-down":        {"d", "Ctrl+F", "Space", "PgDn"},
+		"top":              {"gg", "Home"},
+		"bottom":           {"G", "End"},
+		"open":             {"l", "Enter"},
+		"hints":            {"f", ";"},
+		"back":             {"h", "H", "Backspace"},
+		"forward":          {"L"},
+		"refresh":          {"r"},
+		"input":            {"o"},
+		"bookmarks":        {"gb"},
+		"history":          {"gh"},
+		"diagnostics":      {"D"},
+		"help":             {"g?", "F1"},
+		"new-tab":          {"t"},
+		"open-tab":         {"T"},
+		"close-tab":        {"x"},
+		"next-tab":         {"gt", "K", "Tab"},
+		"previous-tab":     {"gT", "J", "Shift+Tab"},
+		"quit":             {"q", "ZZ", "Ctrl+C"},
+	},
+	"emacs": {
+		"up":            {"Ctrl+P", "Up"},
+		"down":          {"Ctrl+N", "Down"},
+		"page-up":       {"Alt+v", "PgUp"},
+		"page-down":     {"Ctrl+V", "Space", "PgDn"},
+		"top":           {"Alt+<", "Home"},
+		"bottom":        {"Alt+>", "End"},
+		"back":          {"l", "Backspace"},
+		"forward":       {"r"},
+		"refresh":       {"g"},
+		"input":         {"G", "Ctrl+X Ctrl+F"},
+		"bookmark":      {"b"},
+		"bookmarks":     {"B"},
+		"history":       {"H"},
+		"close-tab":     {"Ctrl+X k", "Ctrl+W"},
+		"next-tab":      {"Ctrl+X o", "Tab"},
+		"cancel":        {"Ctrl+G", "Esc"},
+		"find":          {"Ctrl+S", "/"},
+		"find-backward": {"Ctrl+R", "?"},
+		"command":       {"Alt+x", ":"},
+		"quit":          {"q", "Ctrl+X Ctrl+C"},
+	},
+}
+
+var keyNames = []struct {
+	name string
+	key  tcell.Key
+}{
+	{"Enter", tcell.KeyEnter},
+	{"Esc", tcell.KeyEscape},
+	{"Escape", tcell.KeyEscape},
+	{"Tab", tcell.KeyTab},
+	{"Shift+Tab", tcell.KeyBacktab},
+	{"Backtab", tcell.KeyBacktab},
+	{"Backspace", tcell.KeyBackspace},
+	{"Delete", tcell.KeyDelete},
+	{"Insert", tcell.KeyInsert},
+	{"Up", tcell.KeyUp},
+	{"Down", tcell.KeyDown},
+	{"Left", tcell.KeyLeft},
+	{"Right", tcell.KeyRight},
+	{"PgUp", tcell.KeyPgUp},
+	{"PageUp", tcell.KeyPgUp},
+	{"PgDn", tcell.KeyPgDn},
+	{"PageDown", tcell.KeyPgDn},
+	{"Home", tcell.KeyHome},
+	{"End", tcell.KeyEnd},
+}
+
+// keyStroke is a single key pressed by the user, only Alt is kept from the modifiers
+type keyStroke struct {
+	key  tcell.Key
+	char rune
+	alt  bool
+}
+
+func newKeyStroke(event *tcell.EventKey) keyStroke {
+	stroke := keyStroke{key: event.Key(), alt: event.Modifiers()&tcell.ModAlt != 0}
+	if stroke.key == tcell.KeyRune {
+		stroke.char = event.Rune()
+	} else if stroke.key == tcell.KeyBackspace2 {
+		stroke.key = tcell.KeyBackspace
+	}
+	return stroke
+}
+
+// parseKeys reads a key sequence like `Ctrl+X k` or `gg`: keys are separated by spaces,
+// a word which isn't the name of a key being a sequence of characters
+func parseKeys(spec string) ([]keyStroke, error) {
+	sequence := []keyStroke{}
+	for _, word := range strings.Fields(spec) {
+		strokes, err := parseKeyWord(word)
+		if err != nil {
+			return nil, err
+		}
+		sequence = append(sequence, strokes...)
+	}
+	if len(sequence) == 0 {
+		return nil, fmt.Errorf("empty key")
+	}
+	return sequence, nil
+}
+
+func parseKeyWord(word string) ([]keyStroke, error) {
+	lower := strings.ToLower(word)
+	for _, prefix := range []string{"alt+", "m-"} {
+		if strings.HasPrefix(lower, prefix) && len(word) > len(prefix) {
+			strokes, err := parseKeyWord(word[len(prefix):])
+			if err != nil || len(strokes) != 1 {
+				return nil, fmt.Errorf("invalid key `%s`", word)
+			}
+			strokes[0].alt = true
+			return strokes, nil
+		}
+	}
+	for _, prefix := range []string{"ctrl+", "c-"} {
+		if strings.HasPrefix(lower, prefix) && len(word) > len(prefix) {
+			letter := lower[len(prefix):]
+			if len(letter) != 1 || letter[0] < 'a' || letter[0] > 'z' {
+				return nil, fmt.Errorf("invalid key `%s`", word)
+			}
+			return []keyStroke{{key: tcell.KeyCtrlA + tcell.Key(letter[0]-'a')}}, nil
+		}
+	}
+	for _, named := range keyNames {
+		if strings.ToLower(named.name) == lower {
+			return []keyStroke{{key: named.key}}, nil
+		}
+	}
+	if lower == "space" {
+		return []keyStroke{{key: tcell.KeyRune, char: ' '}}, nil
+	}
+	if len(lower) > 1 && lower[0] == 'f' {
+		if number, err := strconv.Atoi(lower[1:]); err == nil && number >= 1 && number <= 12 {
+			return []keyStroke{{key: tcell.KeyF1 + tcell.Key(number-1)}}, nil
+		}
+	}
+	strokes := []keyStroke{}
+	for _, char := range word {
+		strokes = append(strokes, keyStroke{key: tcell.KeyRune, char: char})
+	}
+	return strokes, nil
+}
+
+// String returns the name of the key as written in the configuration
+func (stroke keyStroke) String() string {
+	name := ""
+	switch {
+	case stroke.key == tcell.KeyRune && stroke.char == ' ':
+		name = "Space"
+	case stroke.key == tcell.KeyRune:
+		name = string(stroke.char)
+	case stroke.key >= tcell.KeyF1 && stroke.key <= tcell.KeyF12:
+		name = fmt.Sprintf("F%d", stroke.key-tcell.KeyF1+1)
+	default:
+		for _, named := range keyNames {
+			if named.key == stroke.key {
+				name = named.name
+				break
+			}
+		}
+		if name == "" && stroke.key >= tcell.KeyCtrlA && stroke.key <= tcell.KeyCtrlZ {
+			name = fmt.Sprintf("Ctrl+%c", 'A'+rune(stroke.key-tcell.KeyCtrlA))
+		}
+		if name == "" {
+			name = fmt.Sprintf("Key%d", stroke.key)
+		}
+	}
+	if stroke.alt {
+		name = "Alt+" + name
+	}
+	return name
+}
+
+// formatKeys writes a key sequence the way parseKeys reads it, characters typed in a row are kept together (e.g. `gg`)
+func formatKeys(sequence []keyStroke) string {
+	result := ""
+	for i, stroke := range sequence {
+		if i > 0 && !(isPlainKey(stroke) && isPlainKey(sequence[i-1])) {
+			result += " "
+		}
+		result += stroke.String()
+	}
+	return result
+}
+
+func isPlainKey(stroke keyStroke) bool {
+	return stroke.key == tcell.KeyRune && stroke.char != ' ' && !stroke.alt
+}
+
+// Keymap maps each action to the key sequences which run it
+type Keymap struct {
+	bindings map[string][][]keyStroke
+}
+
+// NewKeymap builds the keymap of `config`, its bindings replacing the ones of its preset
+func NewKeymap(config ConfigKeys) (*Keymap, error) {
+	preset := config.Preset
+	if preset == "" {
+		preset = "default"
+	}
+	overrides, found := keyPresets[preset]
+	if !found {
+		return nil, fmt.Errorf("unknown key preset `%s`", config.Preset)
+	}
+
+	keymap := &Keymap{bindings: map[string][][]keyStroke{}}
+	for _, layer := range []map[string][]string{defaultBindings, overrides, config.Bindings} {
+		for action, specs := range layer {
+			if !isKeyAction(action) {
+				return nil, fmt.Errorf("unknown action `%s`", action)
+			}
+			sequences := [][]keyStroke{}
+			for _, spec := range specs {
+				sequence, err := parseKeys(spec)
+				if err != nil {
+					return nil, fmt.Errorf("%s (for `%s`)", err, action)
+				}
+				sequences = append(sequences, sequence)
+			}
+			keymap.bindings[action] = sequences
+		}
+	}
+	return keymap, nil
+}
+
+func isKeyAction(name string) bool {
+	for _, action := range KeyActions {
+		if action == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Keys returns how the key sequences bound to `action` are written, an empty list if it is unbound
+func (keymap *Keymap) Keys(action string) []string {
+	keys := []string{}
+	for _, sequence := range keymap.bindings[action] {
+		keys = append(keys, formatKeys(sequence))
+	}
+	return keys
+}
+
+// Key returns the first key sequence bound to `action`, or an empty string if it is unbound
+func (keymap *Keymap) Key(action string) string {
+	if keys := keymap.Keys(action); len(keys) > 0 {
+		return keys[0]
+	}
+	return ""
+}
+
+// match returns the actions (amongst the `enabled` ones) bound to exactly `typed`,
+// and if some of them are bound to a longer sequence starting with it
+func (keymap *Keymap) match(typed []keyStroke, enabled func(string) bool) ([]string, bool) {
+	actions := []string{}
+	longer := false
+	for _, action := range KeyActions {
+		if !enabled(action) {
+			continue
+		}
+		for _, sequence := range keymap.bindings[action] {
+			if len(sequence) < len(typed) || !sameKeys(sequence[:len(typed)], typed) {
+				continue
+			}
+			if len(sequence) == len(typed) {
+				actions = append(actions, action)
+			} else {
+				longer = true
+			}
+		}
+	}
+	return actions, longer
+}
+
+func sameKeys(a, b []keyStroke) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,116 @@
+package taupe
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKeys(t *testing.T) {
+	cases := []struct {
+		input  string
+		output []keyStroke
+	}{
+		{"q", []keyStroke{{key: tcell.KeyRune, char: 'q'}}},
+		{"gg", []keyStroke{{key: tcell.KeyRune, char: 'g'}, {key: tcell.KeyRune, char: 'g'}}},
+		{"Enter", []keyStroke{{key: tcell.KeyEnter}}},
+		{"pgdn", []keyStroke{{key: tcell.KeyPgDn}}},
+		{"Space", []keyStroke{{key: tcell.KeyRune, char: ' '}}},
+		{"F1", []keyStroke{{key: tcell.KeyF1}}},
+		{"Ctrl+X k", []keyStroke{{key: tcell.KeyCtrlX}, {key: tcell.KeyRune, char: 'k'}}},
+		{"C-x C-c", []keyStroke{{key: tcell.KeyCtrlX}, {key: tcell.KeyCtrlC}}},
+		{"Alt+<", []keyStroke{{key: tcell.KeyRune, char: '<', alt: true}}},
+		{"M-Left", []keyStroke{{key: tcell.KeyLeft, alt: true}}},
+	}
+	for _, test := range cases {
+		output, err := parseKeys(test.input)
+		if assert.NoError(t, err, "Expected %q to be parsed", test.input) {
+			assert.Equal(t, test.output, output)
+		}
+	}
+}
+
+func TestFailParseKeys(t *testing.T) {
+	cases := []struct {
+		input string
+		err   string
+	}{
+		{"", "empty key"},
+		{"Ctrl+1", "invalid key `Ctrl+1`"},
+		{"Alt+gg", "invalid key `Alt+gg`"},
+	}
+	for _, test := range cases {
+		_, err := parseKeys(test.input)
+		if assert.Error(t, err, "Expected %q to fail", test.input) {
+			assert.Equal(t, test.err, err.Error())
+		}
+	}
+}
+
+func TestFormatKeys(t *testing.T) {
+	cases := []string{"q", "gg", "Enter", "Shift+Tab", "Space", "F5", "Ctrl+X k", "Ctrl+X Ctrl+C", "Alt+v", "g Enter"}
+	for _, test := range cases {
+		sequence, err := parseKeys(test)
+		if assert.NoError(t, err, "Expected %q to be parsed", test) {
+			assert.Equal(t, test, formatKeys(sequence))
+		}
+	}
+}
+
+func TestKeymapPresets(t *testing.T) {
+	for preset := range keyPresets {
+		_, err := NewKeymap(ConfigKeys{Preset: preset})
+		assert.NoError(t, err, "Expected preset %q to be valid", preset)
+	}
+
+	keymap, _ := NewKeymap(ConfigKeys{Preset: "vi", Bindings: map[string][]string{"quit": {"Ctrl+Q"}}})
+	assert.Equal(t, []string{"gg", "Home"}, keymap.Keys("top"))
+	assert.Equal(t, []string{"Ctrl+Q"}, keymap.Keys("quit"))
+	assert.Equal(t, "l", keymap.Key("open"))
+	assert.Equal(t, "", keymap.Key("unknown"))
+}
+
+func TestFailNewKeymap(t *testing.T) {
+	cases := []struct {
+		input ConfigKeys
+		err   string
+	}{
+		{ConfigKeys{Preset: "nano"}, "unknown key preset `nano`"},
+		{ConfigKeys{Bindings: map[string][]string{"fly": {"f"}}}, "unknown action `fly`"},
+		{ConfigKeys{Bindings: map[string][]string{"quit": {"Ctrl+?"}}}, "invalid key `Ctrl+?` (for `quit`)"},
+	}
+	for _, test := range cases {
+		_, err := NewKeymap(test.input)
+		if assert.Error(t, err, "Expected %v to fail", test.input) {
+			assert.Equal(t, test.err, err.Error())
+		}
+	}
+}
+
+func TestKeymapMatch(t *testing.T) {
+	keymap, _ := NewKeymap(ConfigKeys{Preset: "vi"})
+	all := func(string) bool { return true }
+	g, _ := parseKeys("g")
+	gg, _ := parseKeys("gg")
+	esc, _ := parseKeys("Esc")
+
+	actions, longer := keymap.match(g, all)
+	assert.Empty(t, actions)
+	assert.True(t, longer)
+
+	actions, longer = keymap.match(gg, all)
+	assert.Equal(t, []string{"top"}, actions)
+	assert.False(t, longer)
+
+	actions, _ = keymap.match(esc, all)
+	assert.Equal(t, []string{"cancel"}, actions)
+	actions, _ = keymap.match(esc, func(action string) bool { return action != "cancel" })
+	assert.Empty(t, actions)
+
+	for preset := range keyPresets {
+		keymap, _ = NewKeymap(ConfigKeys{Preset: preset})
+		actions, _ = keymap.match(esc, all)
+		assert.NotContains(t, actions, "quit", "Expected Esc not to quit with preset %q", preset)
+	}
+}
@@ -1,28 +1,56 @@
 package taupe
 
+import (
+	"context"
+	"sync"
+
+	"github.com/LouisBrunner/taupe/core"
+)
+
 type netOp int
 
 const (
 	opStop netOp = iota
 	opRequest
+	opDownload
 )
 
 type netCmd struct {
 	op      netOp
+	id      uint64
+	ctx     context.Context
 	address string
-	replyTo chan<- *NetworkEvent
+	path    string
+	data    []string
+	policy  CachePolicy
 }
 
-// Network starts its own thread to handle network requests asynchronously
+// Network starts its own thread to dispatch network requests, each of them running asynchronously
 type Network struct {
+	config      *Config
 	subscribers []chan *NetworkEvent
 	events      chan netCmd
+	mutex       sync.Mutex
+	lastID      uint64
+	requests    map[uint64]context.CancelFunc
+	slots       chan struct{}
+	hosts       map[string]chan struct{}
+	knownHosts  *knownHosts
+	plaintext   map[string]bool
+	cache       *netCache
 }
 
 // NewNetwork builds a valid Network structure with channels, etc
-func NewNetwork() *Network {
+func NewNetwork(config *Config) *Network {
 	return &Network{
-		events: make(chan netCmd, 10),
+		config:     config,
+		events:     make(chan netCmd, 10),
+		requests:   map[uint64]context.CancelFunc{},
+		slots:      make(chan struct{}, imax(config.Connections.Total, 1)),
+		hosts:      map[string]chan struct{}{},
+		knownHosts: newKnownHosts(config.TLS.KnownHosts),
+		plaintext:  map[string]bool{},
+		cache:      newCache(config.Cache),
 	}
 }
 
@@ -31,16 +59,67 @@ func (network *Network) Start() {
 	go network.loop()
 }
 
-// Stop sends the stop event to the internal network thread
+// Stop cancels all the requests in progress and terminates the internal network thread
 func (network *Network) Stop() {
+	network.mutex.Lock()
+	for _, cancel := range network.requests {
+		cancel()
+	}
+	network.mutex.Unlock()
 	network.events <- netCmd{op: opStop}
 }
 
-// Request sends a cancel event for the current request and starts a new request to the provided `address`
-func (network *Network) Request(address string) <-chan *NetworkEvent {
-	replyTo := make(chan *NetworkEvent)
-	network.events <- netCmd{op: opRequest, address: address, replyTo: replyTo}
-	return replyTo
+// Subscribe returns a channel which will receive the events of every request
+func (network *Network) Subscribe() <-chan *NetworkEvent {
+	network.mutex.Lock()
+	defer network.mutex.Unlock()
+	subscriber := make(chan *NetworkEvent, 100)
+	network.subscribers = append(network.subscribers, subscriber)
+	return subscriber
+}
+
+// Request starts a new request to the provided `address`, its answer will carry the returned ID
+// `policy` decides if the answer can come from the cache
+func (network *Network) Request(address string, policy CachePolicy) uint64 {
+	return network.schedule(netCmd{op: opRequest, address: address, policy: policy})
+}
+
+// Post starts a new request to the provided Gopher+ `address`, sending `data` (e.g. the answers to a +ASK form) along
+func (network *Network) Post(address string, data []string) uint64 {
+	return network.schedule(netCmd{op: opRequest, address: address, data: data})
+}
+
+// Download starts saving the provided `address` to the file at `path`, progress is reported until completion with the returned ID
+func (network *Network) Download(address, path string) uint64 {
+	return network.schedule(netCmd{op: opDownload, address: address, path: path})
+}
+
+// Cancel aborts the request with the provided `id`, no more events will be sent for it
+func (network *Network) Cancel(id uint64) {
+	network.mutex.Lock()
+	defer network.mutex.Unlock()
+	if cancel, ok := network.requests[id]; ok {
+		cancel()
+		delete(network.requests, id)
+	}
+}
+
+// Trust replaces the certificate pinned for `host` by the one with the provided `fingerprint`
+func (network *Network) Trust(host, fingerprint string) error {
+	return network.knownHosts.trust(host, fingerprint)
+}
+
+func (network *Network) schedule(cmd netCmd) uint64 {
+	network.mutex.Lock()
+	network.lastID++
+	cmd.id = network.lastID
+	var cancel context.CancelFunc
+	cmd.ctx, cancel = context.WithCancel(context.Background())
+	network.requests[cmd.id] = cancel
+	network.mutex.Unlock()
+
+	network.events <- cmd
+	return cmd.id
 }
 
 func (network *Network) loop() {
@@ -49,10 +128,126 @@ func (network *Network) loop() {
 		case event := <-network.events:
 			switch event.op {
 			case opStop:
-				break
-			case opRequest:
-				event.replyTo <- network.doRequest(event.address)
+				return
+			case opRequest, opDownload:
+				go network.run(event)
 			}
 		}
 	}
 }
+
+func (network *Network) run(cmd netCmd) {
+	defer network.Cancel(cmd.id)
+
+	if cmd.op == opRequest && cmd.data == nil {
+		if cached := network.cache.get(cmd.address, cmd.policy); cached != nil {
+			network.publish(cmd, cached)
+			return
+		}
+	}
+	if network.config.Cache.Offline {
+		network.publish(cmd, createErrorEvent(ErrNetworkOffline))
+		return
+	}
+
+	release, ok := network.acquire(cmd.ctx, cmd.address)
+	if !ok {
+		return
+	}
+	defer release()
+
+	switch cmd.op {
+	case opRequest:
+		// Menus are streamed in chunks, the cache needs all of them
+		chunks := &NetworkResult{}
+		report := func(partial *NetworkEvent) {
+			if partial.Event == NetworkEventChunk {
+				chunks.Records = append(chunks.Records, partial.Result.Records...)
+				chunks.Warnings = append(chunks.Warnings, partial.Result.Warnings...)
+			}
+			network.publish(cmd, partial)
+		}
+		event := network.doRequest(cmd.ctx, cmd.address, cmd.data, report)
+		if cmd.data == nil {
+			network.cache.put(cmd.address, completeEvent(event, chunks))
+		}
+		network.publish(cmd, event)
+	case opDownload:
+		report := func(progress *NetworkResultDownload) {
+			network.publish(cmd, &NetworkEvent{Event: NetworkEventProgress, ResultDownload: progress})
+		}
+		network.publish(cmd, network.doDownload(cmd.ctx, cmd.address, cmd.path, report))
+	}
+}
+
+// completeEvent returns the final `event` of a request including the records sent in previous `chunks`
+func completeEvent(event *NetworkEvent, chunks *NetworkResult) *NetworkEvent {
+	if event.Event != NetworkEventOK || len(chunks.Records)+len(chunks.Warnings) == 0 {
+		return event
+	}
+	complete := *event
+	complete.Result = &NetworkResult{
+		Address:  event.Result.Address,
+		Records:  append(chunks.Records, event.Result.Records...),
+		Warnings: append(chunks.Warnings, event.Result.Warnings...),
+	}
+	return &complete
+}
+
+// acquire waits for a free connection slot, both for the host of `address` and globally
+// The host slot is taken first so requests to a busy host don't hold the global ones
+func (network *Network) acquire(ctx context.Context, address string) (func(), bool) {
+	host := network.hostSlots(address)
+	select {
+	case host <- struct{}{}:
+	case <-ctx.Done():
+		return nil, false
+	}
+
+	select {
+	case network.slots <- struct{}{}:
+	case <-ctx.Done():
+		<-host
+		return nil, false
+	}
+
+	return func() {
+		<-network.slots
+		<-host
+	}, true
+}
+
+func (network *Network) hostSlots(address string) chan struct{} {
+	key := address
+	if url, err := core.ParseURL(address); err == nil {
+		key = url.HostPort()
+	}
+
+	network.mutex.Lock()
+	defer network.mutex.Unlock()
+	slots, ok := network.hosts[key]
+	if !ok {
+		slots = make(chan struct{}, imax(network.config.Connections.PerHost, 1))
+		network.hosts[key] = slots
+	}
+	return slots
+}
+
+func (network *Network) publish(cmd netCmd, event *NetworkEvent) {
+	if cmd.ctx.Err() != nil {
+		return
+	}
+	event.ID = cmd.id
+
+	network.mutex.Lock()
+	subscribers := network.subscribers
+	network.mutex.Unlock()
+
+	for _, subscriber := range subscribers {
+		select {
+		case subscriber <- event:
+		case <-cmd.ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package taupe
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/LouisBrunner/taupe/core"
+)
+
+type cacheEntry struct {
+	Key   string        `json:"key"`
+	Time  time.Time     `json:"time"`
+	Event *NetworkEvent `json:"event"`
+}
+
+// netCache keeps the most recent answers in memory (least recently used ones are dropped first),
+// and optionally all of them on disk
+type netCache struct {
+	mutex   sync.Mutex
+	config  ConfigCache
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+func newCache(config ConfigCache) *netCache {
+	return &netCache{config: config, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+// cacheKey normalizes `address` so different spellings of the same URL share their entry
+func cacheKey(address string) string {
+	if url, err := core.ParseURL(address); err == nil {
+		return url.String()
+	}
+	return address
+}
+
+// get returns a copy of the cached answer for `address` if `policy` allows using it
+func (cache *netCache) get(address string, policy CachePolicy) *NetworkEvent {
+	if policy == CacheReload && !cache.config.Offline {
+		return nil
+	}
+	key := cacheKey(address)
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	entry := cache.lookup(key)
+	if entry == nil {
+		return nil
+	}
+	fresh := time.Since(entry.Time) < cache.config.TTL.Duration
+	if policy == CacheDefault && !fresh && !cache.config.Offline {
+		return nil
+	}
+	event := *entry.Event
+	event.Cached = true
+	return &event
+}
+
+func (cache *netCache) lookup(key string) *cacheEntry {
+	if element, ok := cache.entries[key]; ok {
+		cache.order.MoveToFront(element)
+		return element.Value.(*cacheEntry)
+	}
+	entry := cache.load(key)
+	if entry != nil {
+		cache.remember(entry)
+	}
+	return entry
+}
+
+// put caches a complete answer for `address`, errors are never cached
+func (cache *netCache) put(address string, event *NetworkEvent) {
+	switch event.Event {
+	case NetworkEventOK, NetworkEventHTML, NetworkEventText, NetworkEventAttributes:
+	default:
+		return
+	}
+	stored := *event
+	entry := &cacheEntry{Key: cacheKey(address), Time: time.Now(), Event: &stored}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.remember(entry)
+	cache.save(entry)
+}
+
+func (cache *netCache) remember(entry *cacheEntry) {
+	if cache.config.Size <= 0 {
+		return
+	}
+	if element, ok := cache.entries[entry.Key]; ok {
+		element.Value = entry
+		cache.order.MoveToFront(element)
+		return
+	}
+	cache.entries[entry.Key] = cache.order.PushFront(entry)
+	for cache.order.Len() > cache.config.Size {
+		oldest := cache.order.Back()
+		cache.order.Remove(oldest)
+		delete(cache.entries, oldest.Value.(*cacheEntry).Key)
+	}
+}
+
+func (cache *netCache) path(key string) string {
+	return filepath.Join(cache.config.Dir, fmt.Sprintf("%x.json", sha256.Sum256([]byte(key))))
+}
+
+// The disk store is only a best effort, failing to use it is the same as a cache miss
+func (cache *netCache) load(key string) *cacheEntry {
+	if cache.config.Dir == "" {
+		return nil
+	}
+	content, err := ioutil.ReadFile(cache.path(key))
+	if err != nil {
+		return nil
+	}
+	entry := &cacheEntry{}
+	if json.Unmarshal(content, entry) != nil || entry.Key != key || entry.Event == nil {
+		return nil
+	}
+	return entry
+}
+
+func (cache *netCache) save(entry *cacheEntry) {
+	if cache.config.Dir == "" {
+		return
+	}
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if os.MkdirAll(cache.config.Dir, 0700) != nil {
+		return
+	}
+	ioutil.WriteFile(cache.path(entry.Key), content, 0600)
+}
@@ -0,0 +1,81 @@
+package taupe
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/LouisBrunner/taupe/core"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func textEvent(address string) *NetworkEvent {
+	return &NetworkEvent{Event: NetworkEventText, ResultText: &NetworkResultText{Address: address, Lines: []string{address}}}
+}
+
+func testMenu(displays ...string) []*core.Record {
+	records := []*core.Record{}
+	for _, display := range displays {
+		record, _ := core.ParseRecord("1" + display + "\t/" + display + "\thost\t70")
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestCacheKey(t *testing.T) {
+	assert.Equal(t, cacheKey("gopher://sdf.org/1"), cacheKey("GOPHER://sdf.org:70/1"))
+	assert.Equal(t, cacheKey("gopher://sdf.org"), cacheKey("gopher://sdf.org/1"))
+	assert.NotEqual(t, cacheKey("gopher://sdf.org/1"), cacheKey("gopher://sdf.org/0"))
+}
+
+func TestCacheLRU(t *testing.T) {
+	cache := newCache(ConfigCache{Size: 2, TTL: Duration{time.Minute}})
+	cache.put("gopher://a/1", textEvent("a"))
+	cache.put("gopher://b/1", textEvent("b"))
+	assert.NotNil(t, cache.get("gopher://a/1", CacheDefault))
+	cache.put("gopher://c/1", textEvent("c"))
+
+	assert.NotNil(t, cache.get("gopher://a/1", CacheDefault))
+	assert.Nil(t, cache.get("gopher://b/1", CacheDefault))
+	assert.NotNil(t, cache.get("gopher://c/1", CacheDefault))
+	assert.True(t, cache.get("gopher://c/1", CacheDefault).Cached)
+}
+
+func TestCachePolicy(t *testing.T) {
+	cache := newCache(ConfigCache{Size: 2})
+	cache.put("gopher://a/1", textEvent("a"))
+	cache.put("gopher://b/1", createErrorEvent(ErrNetworkCanceled))
+
+	assert.Nil(t, cache.get("gopher://a/1", CacheDefault), "Expected stale entries to be ignored")
+	assert.NotNil(t, cache.get("gopher://a/1", CachePrefer))
+	assert.Nil(t, cache.get("gopher://a/1", CacheReload))
+	assert.Nil(t, cache.get("gopher://b/1", CachePrefer), "Expected errors not to be cached")
+
+	cache.config.Offline = true
+	assert.NotNil(t, cache.get("gopher://a/1", CacheDefault))
+	assert.NotNil(t, cache.get("gopher://a/1", CacheReload))
+}
+
+func TestCacheDisk(t *testing.T) {
+	dir, err := ioutil.TempDir("", "taupe")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	newCache(ConfigCache{Dir: dir}).put("gopher://a/1", textEvent("a"))
+	event := newCache(ConfigCache{Size: 1, Dir: dir}).get("gopher://a/1", CachePrefer)
+	if assert.NotNil(t, event) {
+		assert.Equal(t, []string{"a"}, event.ResultText.Lines)
+	}
+}
+
+func TestCompleteEvent(t *testing.T) {
+	chunks := &NetworkResult{Records: testMenu("a", "b")}
+	event := &NetworkEvent{Event: NetworkEventOK, Result: &NetworkResult{Address: "gopher://a/1", Records: testMenu("c")}}
+	complete := completeEvent(event, chunks)
+	assert.Len(t, complete.Result.Records, 3)
+	assert.Len(t, event.Result.Records, 1)
+}
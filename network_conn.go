@@ -0,0 +1,90 @@
+package taupe
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"time"
+)
+
+// netConn enforces the configured timeouts and the request cancellation on a connection
+// When `tls` is set, the data goes through it instead of the raw connection
+type netConn struct {
+	net.Conn
+	tls     *tls.Conn
+	ctx     context.Context
+	address string
+	timeout time.Duration
+	total   time.Duration
+	done    chan struct{}
+	failure error
+}
+
+func watchConn(ctx context.Context, conn net.Conn, address string, timeout, total time.Duration) *netConn {
+	watched := &netConn{Conn: conn, ctx: ctx, address: address, timeout: timeout, total: total, done: make(chan struct{})}
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-watched.done:
+		}
+	}()
+	return watched
+}
+
+func (conn *netConn) Read(buffer []byte) (int, error) {
+	if conn.timeout > 0 {
+		conn.Conn.SetReadDeadline(time.Now().Add(conn.timeout))
+	}
+	var n int
+	var err error
+	if conn.tls != nil {
+		n, err = conn.tls.Read(buffer)
+	} else {
+		n, err = conn.Conn.Read(buffer)
+	}
+	if err != nil && conn.failure == nil {
+		if ctxErr := contextError(conn.ctx, conn.address, "completing", conn.total); ctxErr != nil {
+			conn.failure = ctxErr
+		} else if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			conn.failure = &NetworkTimeoutError{Address: conn.address, Stage: "reading", Timeout: conn.timeout}
+		}
+		if conn.failure != nil {
+			err = conn.failure
+		}
+	}
+	return n, err
+}
+
+func (conn *netConn) Write(buffer []byte) (int, error) {
+	if conn.tls != nil {
+		return conn.tls.Write(buffer)
+	}
+	return conn.Conn.Write(buffer)
+}
+
+func (conn *netConn) Close() error {
+	close(conn.done)
+	if conn.tls != nil {
+		return conn.tls.Close()
+	}
+	return conn.Conn.Close()
+}
+
+// check replaces `err` by the cancellation or timeout which caused it, if any
+func (conn *netConn) check(err error) error {
+	if conn.failure != nil {
+		return conn.failure
+	}
+	return err
+}
+
+func contextError(ctx context.Context, address, stage string, timeout time.Duration) error {
+	switch ctx.Err() {
+	case context.Canceled:
+		return ErrNetworkCanceled
+	case context.DeadlineExceeded:
+		return &NetworkTimeoutError{Address: address, Stage: stage, Timeout: timeout}
+	}
+	return nil
+}
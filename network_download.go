@@ -0,0 +1,78 @@
+package taupe
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/LouisBrunner/taupe/core"
+)
+
+const progressInterval = 100 * time.Millisecond
+
+func (network *Network) doDownload(ctx context.Context, request, path string, report func(*NetworkResultDownload)) *NetworkEvent {
+	conn, url, err := network.open(ctx, request, nil, 0)
+	if err != nil {
+		return createErrorEvent(err)
+	}
+	defer conn.Close()
+
+	var reader io.Reader = conn
+	if url.Plus != "" {
+		if reader, err = core.ReadPlusResponse(bufio.NewReader(conn)); err != nil {
+			return createErrorEvent(conn.check(err))
+		}
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return createErrorEvent(fmt.Errorf("cannot create directory for `%s`: %s", path, err))
+	}
+	partial := path + ".part"
+	file, err := os.Create(partial)
+	if err != nil {
+		return createErrorEvent(fmt.Errorf("cannot create `%s`: %s", path, err))
+	}
+
+	result := &NetworkResultDownload{Address: request, Path: path}
+	started, reported := time.Now(), time.Now()
+	buffer := make([]byte, 32*1024)
+	for {
+		n, err := reader.Read(buffer)
+		if n > 0 {
+			if _, werr := file.Write(buffer[:n]); werr != nil {
+				file.Close()
+				os.Remove(partial)
+				return createErrorEvent(fmt.Errorf("while writing `%s`: %s", path, werr))
+			}
+			result.Size += int64(n)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			file.Close()
+			os.Remove(partial)
+			return createErrorEvent(conn.check(fmt.Errorf("while downloading: %s", err)))
+		}
+		if time.Since(reported) >= progressInterval {
+			reported = time.Now()
+			result.Rate = float64(result.Size) / time.Since(started).Seconds()
+			progress := *result
+			report(&progress)
+		}
+	}
+
+	if err = file.Close(); err != nil {
+		os.Remove(partial)
+		return createErrorEvent(fmt.Errorf("while writing `%s`: %s", path, err))
+	}
+	if err = os.Rename(partial, path); err != nil {
+		return createErrorEvent(fmt.Errorf("cannot save `%s`: %s", path, err))
+	}
+	result.Rate = float64(result.Size) / time.Since(started).Seconds()
+	return &NetworkEvent{Event: NetworkEventDownload, ResultDownload: result}
+}
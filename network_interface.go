@@ -1,8 +1,63 @@
 package taupe
 
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/LouisBrunner/taupe/core"
+)
+
 // NetworkManager is a class that can do Gopher requests
 type NetworkManager interface {
-	Request(string) <-chan *NetworkEvent
+	Subscribe() <-chan *NetworkEvent
+	Request(string, CachePolicy) uint64
+	Post(string, []string) uint64
+	Download(string, string) uint64
+	Cancel(uint64)
+	Trust(string, string) error
+}
+
+// CachePolicy tells the NetworkManager when a cached answer can be used instead of requesting the server again
+type CachePolicy int
+
+// Possible ways of using the cache
+const (
+	// CacheDefault uses the cached answer if it is more recent than the configured TTL
+	CacheDefault CachePolicy = iota
+	// CachePrefer uses the cached answer whatever its age (e.g. when going back to a page)
+	CachePrefer
+	// CacheReload always requests the server again (e.g. when refreshing a page)
+	CacheReload
+)
+
+// ErrNetworkOffline is the error returned by a request which isn't cached while in offline mode
+var ErrNetworkOffline = errors.New("page not cached, cannot request it in offline mode")
+
+// ErrNetworkCanceled is the error returned by a request which was canceled before completing
+var ErrNetworkCanceled = errors.New("request canceled")
+
+// NetworkTimeoutError is the error returned by a request which exceeded one of the configured timeouts
+type NetworkTimeoutError struct {
+	Address string
+	Stage   string
+	Timeout time.Duration
+}
+
+func (err *NetworkTimeoutError) Error() string {
+	return fmt.Sprintf("timed out %s `%s` after %v", err.Stage, err.Address, err.Timeout)
+}
+
+// NetworkCertificateError is the error returned when a server presents a different certificate than the one pinned for it
+type NetworkCertificateError struct {
+	Address     string
+	Host        string
+	Known       string
+	Fingerprint string
+}
+
+func (err *NetworkCertificateError) Error() string {
+	return fmt.Sprintf("certificate of `%s` changed (known %s, received %s)", err.Host, err.Known, err.Fingerprint)
 }
 
 // NetworkEventType is a type of event that be returned by the NetworkManager
@@ -11,22 +66,38 @@ type NetworkEventType int
 // Type of possible network results
 const (
 	NetworkEventOK NetworkEventType = iota
+	NetworkEventChunk
 	NetworkEventHTML
+	NetworkEventText
+	NetworkEventAttributes
+	NetworkEventProgress
+	NetworkEventDownload
 	NetworkEventError
 )
 
 // NetworkEvent represents any answer from the Network
 type NetworkEvent struct {
-	Event       NetworkEventType
-	Result      *NetworkResult
-	ResultHTML  *NetworkResultHTML
-	ResultError error
+	ID               uint64
+	Event            NetworkEventType
+	Result           *NetworkResult
+	ResultHTML       *NetworkResultHTML
+	ResultText       *NetworkResultText
+	ResultAttributes *NetworkResultAttributes
+	ResultDownload   *NetworkResultDownload
+	ResultError      error
+	// Secure is set when the answer was received over TLS
+	Secure bool
+	// Cached is set when the answer comes from the cache instead of the server
+	Cached bool
 }
 
 // NetworkResult is a Gopher answer from a request to the NetworkManager class
+// Big menus are sent in several NetworkEventChunk events followed by a NetworkEventOK,
+// each of them only containing the records (and parsing warnings) found since the previous one
 type NetworkResult struct {
-	Address string
-	List    []string
+	Address  string
+	Records  []*core.Record
+	Warnings []core.MenuWarning
 }
 
 // NetworkResultHTML is a HTML answer from a request to the NetworkManager class
@@ -34,3 +105,23 @@ type NetworkResultHTML struct {
 	Address string
 	HTML    string
 }
+
+// NetworkResultText is a text file answer from a request to the NetworkManager class
+type NetworkResultText struct {
+	Address string
+	Lines   []string
+}
+
+// NetworkResultAttributes is the answer to a Gopher+ attributes request (`!` or `$`) to the NetworkManager class
+type NetworkResultAttributes struct {
+	Address string
+	Items   []*core.Attributes
+}
+
+// NetworkResultDownload is the state of a file being saved to disk by the NetworkManager class
+type NetworkResultDownload struct {
+	Address string
+	Path    string
+	Size    int64
+	Rate    float64
+}
@@ -2,68 +2,134 @@ package taupe
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net"
-	"net/url"
 	"strings"
+	"time"
 
 	"github.com/LouisBrunner/taupe/core"
 )
 
 const crlf, eom string = "\r\n", "."
 
-func (network *Network) doRequest(request string) *NetworkEvent {
-	url, err := url.Parse(request)
-	if err != nil {
-		return createErrorEvent(fmt.Errorf("invalid url `%s`: %s", request, err))
-	}
-	if url.Scheme != "gopher" && url.Scheme != "" {
-		return createErrorEvent(fmt.Errorf("invalid scheme `%s`", url.Scheme))
-	}
-	if url.Host == "" {
-		return createErrorEvent(fmt.Errorf("missing host for `%s`", request))
-	}
-
-	port := "70"
-	if url.Port() != "" {
-		port = url.Port()
+func (network *Network) doRequest(ctx context.Context, request string, data []string, report func(*NetworkEvent)) *NetworkEvent {
+	total := network.config.Timeouts.Request.Duration
+	if total > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, total)
+		defer cancel()
 	}
 
-	host := fmt.Sprintf("%s:%s", url.Hostname(), port)
-	conn, err := net.Dial("tcp", host)
-	defer conn.Close()
+	conn, url, err := network.open(ctx, request, data, total)
 	if err != nil {
-		return createErrorEvent(fmt.Errorf("cannot connect to `%s`: %s", host, err))
+		return createErrorEvent(err)
 	}
+	defer conn.Close()
 
-	path := ""
-	if val, ok := url.Query()["q"]; ok {
-		path = val[0]
+	secure := conn.tls != nil
+	forward := report
+	report = func(partial *NetworkEvent) {
+		partial.Secure = secure
+		forward(partial)
 	}
-	fmt.Fprintf(conn, fmt.Sprintf("%s%s", path, crlf))
 
 	reader := bufio.NewReader(conn)
-
-	linkType := core.TypeSubMenu
-	if val, ok := url.Query()["t"]; ok {
-		linkType = core.ParseEntry(val[0][0])
+	if url.Plus != "" {
+		content, err := core.ReadPlusResponse(reader)
+		if err != nil {
+			return createErrorEvent(conn.check(err))
+		}
+		reader = bufio.NewReader(content)
+	}
+	charset, err := network.config.Charset.lookup(url.Host, url.HostPort())
+	if err != nil {
+		return createErrorEvent(err)
+	}
+	if charset != core.CharsetUTF8 {
+		reader = bufio.NewReader(charset.Reader(reader))
 	}
 
-	// TODO: support images, binaries...
 	var event *NetworkEvent
-	if linkType == core.TypeHTML {
+	if url.Plus == core.PlusAttributes || url.Plus == core.PlusDirectoryAttributes {
+		event, err = network.parseAttributes(request, reader)
+	} else if url.Type == core.TypeHTML {
 		event, err = network.parseHTML(request, reader)
+	} else if url.Type == core.TypeFile {
+		event, err = network.parseText(request, reader)
 	} else {
-		event, err = network.parseGopher(request, reader)
+		options := core.MenuOptions{Host: url.Host, Port: url.Port, TLS: secure}
+		event, err = network.parseGopher(request, core.NewMenuReader(reader, options), report)
 	}
 	if err != nil {
-		return createErrorEvent(err)
+		return createErrorEvent(conn.check(err))
 	}
+	event.Secure = secure
 	return event
 }
 
+// open connects to the server of `request` and sends it, followed by a Gopher+ data block if `data` isn't nil
+func (network *Network) open(ctx context.Context, request string, data []string, total time.Duration) (*netConn, *core.URL, error) {
+	url, err := core.ParseURL(request)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	host := url.HostPort()
+	conn, err := network.dial(ctx, request, host, total)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	upgrade := !url.TLS && network.config.TLS.Upgrade && !network.isPlaintext(host)
+	if url.TLS || upgrade {
+		if err = network.handshake(conn, url.Host, host); err != nil {
+			conn.Close()
+			certErr, changed := err.(*NetworkCertificateError)
+			if changed {
+				certErr.Address = request
+			}
+			if !upgrade || changed || ctx.Err() != nil {
+				return nil, nil, conn.check(err)
+			}
+			network.setPlaintext(host)
+			if conn, err = network.dial(ctx, request, host, total); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	message := url.Request() + crlf
+	if data != nil {
+		url.Plus = core.PlusData
+		message = url.Request() + crlf + core.FormatPlusData(data)
+	}
+	if _, err = io.WriteString(conn, message); err != nil {
+		conn.Close()
+		return nil, nil, conn.check(fmt.Errorf("cannot send request to `%s`: %s", host, err))
+	}
+
+	return conn, url, nil
+}
+
+func (network *Network) dial(ctx context.Context, request, host string, total time.Duration) (*netConn, error) {
+	timeouts := network.config.Timeouts
+	dialer := net.Dialer{Timeout: timeouts.Dial.Duration}
+	raw, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		if ctxErr := contextError(ctx, request, "connecting to", total); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return nil, &NetworkTimeoutError{Address: host, Stage: "connecting to", Timeout: timeouts.Dial.Duration}
+		}
+		return nil, fmt.Errorf("cannot connect to `%s`: %s", host, err)
+	}
+	return watchConn(ctx, raw, request, timeouts.Read.Duration, total), nil
+}
+
 func createErrorEvent(err error) *NetworkEvent {
 	return &NetworkEvent{Event: NetworkEventError, ResultError: err}
 }
@@ -79,44 +145,70 @@ func (network *Network) parseHTML(request string, reader io.Reader) (*NetworkEve
 	}, nil
 }
 
-func (network *Network) parseGopher(request string, reader *bufio.Reader) (*NetworkEvent, error) {
+func (network *Network) parseAttributes(request string, reader io.Reader) (*NetworkEvent, error) {
+	items, err := core.ParseAttributes(reader)
+	if err != nil {
+		return nil, err
+	}
+	return &NetworkEvent{
+		Event:            NetworkEventAttributes,
+		ResultAttributes: &NetworkResultAttributes{Address: request, Items: items},
+	}, nil
+}
+
+func (network *Network) parseText(request string, reader *bufio.Reader) (*NetworkEvent, error) {
 	lines := []string{}
 
 	for {
-		char, err := reader.Peek(1)
+		line, err := reader.ReadString(crlf[1])
 		if err != nil && err != io.EOF {
-			return nil, fmt.Errorf("while reading EOM: %s", err)
+			return nil, fmt.Errorf("while reading line: %s", err)
 		}
-		if string(char) == eom || err == io.EOF {
+		line = strings.TrimSuffix(strings.TrimSuffix(line, crlf[1:]), crlf[:1])
+		if line == eom || (err == io.EOF && line == "") {
 			break
 		}
-
-		line := ""
-		for {
-			result, err := reader.ReadString(crlf[0])
-			if err != nil {
-				return nil, fmt.Errorf("while reading line: %s", err)
-			}
-			line += result
-
-			char, err := reader.Peek(1)
-			if err != nil {
-				return nil, fmt.Errorf("while peeking LF: %s", err)
-			}
-			if char[0] == crlf[1] {
-				char, err := reader.ReadByte()
-				if err != nil {
-					return nil, fmt.Errorf("while reading LF: %s", err)
-				}
-				line += string(char)
-				break
-			}
+		if strings.HasPrefix(line, eom+eom) {
+			line = line[1:]
+		}
+		lines = append(lines, line)
+		if err == io.EOF {
+			break
 		}
-		lines = append(lines, strings.Replace(line, crlf, "", -1))
 	}
 
 	return &NetworkEvent{
-		Event:  NetworkEventOK,
-		Result: &NetworkResult{Address: request, List: lines},
+		Event:      NetworkEventText,
+		ResultText: &NetworkResultText{Address: request, Lines: lines},
 	}, nil
 }
+
+func (network *Network) parseGopher(request string, menu *core.MenuReader, report func(*NetworkEvent)) (*NetworkEvent, error) {
+	records := []*core.Record{}
+	reported, warned := time.Now(), 0
+
+	chunk := func() *NetworkResult {
+		warnings := menu.Warnings()
+		result := &NetworkResult{Address: request, Records: records, Warnings: warnings[warned:]}
+		records, warned = []*core.Record{}, len(warnings)
+		return result
+	}
+
+	for {
+		record, err := menu.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+
+		if time.Since(reported) >= progressInterval {
+			reported = time.Now()
+			report(&NetworkEvent{Event: NetworkEventChunk, Result: chunk()})
+		}
+	}
+
+	return &NetworkEvent{Event: NetworkEventOK, Result: chunk()}, nil
+}
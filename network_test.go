@@ -0,0 +1,63 @@
+package taupe
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// serveMenu answers one request on a local listener with `first`, then `rest` after `delay`
+func serveMenu(t *testing.T, first, rest string, delay time.Duration) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		defer listener.Close()
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		bufio.NewReader(conn).ReadString('\n')
+		fmt.Fprint(conn, first)
+		time.Sleep(delay)
+		fmt.Fprint(conn, rest)
+	}()
+	return fmt.Sprintf("gopher://%s/1/", listener.Addr())
+}
+
+func TestNetworkStreamedMenu(t *testing.T) {
+	config := DefaultConfig()
+	config.Cache = ConfigCache{}
+	network := NewNetwork(config)
+	events := network.Subscribe()
+	network.Start()
+	defer network.Stop()
+
+	address := serveMenu(t, "iFirst\t\terror.host\t1\r\n", "iSecond\t\terror.host\t1\r\n.\r\n", 2*progressInterval)
+	id := network.Request(address, CacheDefault)
+
+	kinds, lines := []NetworkEventType{}, 0
+	for {
+		select {
+		case event := <-events:
+			assert.Equal(t, id, event.ID)
+			kinds = append(kinds, event.Event)
+			if event.Result != nil {
+				lines += len(event.Result.Records)
+			}
+			if event.Event != NetworkEventChunk {
+				assert.Equal(t, []NetworkEventType{NetworkEventChunk, NetworkEventOK}, kinds)
+				assert.Equal(t, 2, lines)
+				return
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected the request to finish")
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package taupe
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// knownHosts pins the certificate of each server the first time it is seen (trust on first use)
+type knownHosts struct {
+	path   string
+	mutex  sync.Mutex
+	loaded bool
+	hosts  map[string]string
+}
+
+func newKnownHosts(path string) *knownHosts {
+	return &knownHosts{path: path, hosts: map[string]string{}}
+}
+
+func (known *knownHosts) verify(host, fingerprint string) error {
+	known.mutex.Lock()
+	defer known.mutex.Unlock()
+	known.load()
+
+	pinned, ok := known.hosts[host]
+	if !ok {
+		known.hosts[host] = fingerprint
+		known.save()
+		return nil
+	}
+	if pinned != fingerprint {
+		return &NetworkCertificateError{Host: host, Known: pinned, Fingerprint: fingerprint}
+	}
+	return nil
+}
+
+func (known *knownHosts) trust(host, fingerprint string) error {
+	known.mutex.Lock()
+	defer known.mutex.Unlock()
+	known.load()
+
+	known.hosts[host] = fingerprint
+	return known.save()
+}
+
+func (known *knownHosts) load() {
+	if known.loaded || known.path == "" {
+		return
+	}
+	known.loaded = true
+
+	file, err := os.Open(known.path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		known.hosts[fields[0]] = fields[1]
+	}
+}
+
+func (known *knownHosts) save() error {
+	if known.path == "" {
+		return nil
+	}
+	hosts := make([]string, 0, len(known.hosts))
+	for host := range known.hosts {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	content := ""
+	for _, host := range hosts {
+		content += fmt.Sprintf("%s %s\n", host, known.hosts[host])
+	}
+	if err := os.MkdirAll(filepath.Dir(known.path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(known.path, []byte(content), 0600)
+}
+
+func fingerprint(certificate *x509.Certificate) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(certificate.Raw))
+}
+
+func (network *Network) handshake(conn *netConn, serverName, host string) error {
+	secure := tls.Client(conn.Conn, &tls.Config{
+		ServerName: serverName,
+		// Most Gopher servers use self-signed certificates, they are pinned by knownHosts instead
+		InsecureSkipVerify: true,
+	})
+	if timeout := network.config.Timeouts.Dial.Duration; timeout > 0 {
+		conn.Conn.SetDeadline(time.Now().Add(timeout))
+		defer conn.Conn.SetDeadline(time.Time{})
+	}
+	if err := secure.Handshake(); err != nil {
+		return fmt.Errorf("TLS handshake with `%s` failed: %s", host, err)
+	}
+
+	certificates := secure.ConnectionState().PeerCertificates
+	if len(certificates) < 1 {
+		return fmt.Errorf("`%s` didn't send any certificate", host)
+	}
+	if err := network.knownHosts.verify(host, fingerprint(certificates[0])); err != nil {
+		return err
+	}
+	conn.tls = secure
+	return nil
+}
+
+func (network *Network) isPlaintext(host string) bool {
+	network.mutex.Lock()
+	defer network.mutex.Unlock()
+	return network.plaintext[host]
+}
+
+func (network *Network) setPlaintext(host string) {
+	network.mutex.Lock()
+	defer network.mutex.Unlock()
+	network.plaintext[host] = true
+}
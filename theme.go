@@ -0,0 +1,277 @@
+package taupe
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/LouisBrunner/taupe/core"
+
+	"github.com/gdamore/tcell"
+)
+
+// ThemeElements are the parts of the UI which can be styled, `text` is the base of all the others
+var ThemeElements = []string{
+	"text", "header", "tab", "tab-active", "footer", "status", "prompt", "selection", "link", "match", "match-current", "hint", "scrollbar",
+	"menu", "file", "search", "binary", "html", "telnet", "error", "info",
+}
+
+var themes = map[string]map[string]string{
+	"default": {
+		"header":        "white on navy bold",
+		"tab":           "silver on navy",
+		"tab-active":    "black on silver bold",
+		"footer":        "white on navy",
+		"status":        "yellow on navy bold",
+		"prompt":        "white on navy",
+		"selection":     "black on aqua",
+		"link":          "teal underline",
+		"match":         "black on olive",
+		"match-current": "black on yellow bold",
+		"hint":          "white on maroon bold",
+		"scrollbar":     "teal",
+		"menu":          "blue bold",
+		"file":          "green",
+		"search":        "fuchsia",
+		"binary":        "olive",
+		"html":          "teal",
+		"telnet":        "purple",
+		"error":         "red",
+	},
+	"solarized": {
+		"text":          "#839496 on #002b36",
+		"header":        "#fdf6e3 on #073642 bold",
+		"tab":           "#93a1a1 on #073642",
+		"tab-active":    "#002b36 on #93a1a1 bold",
+		"footer":        "#93a1a1 on #073642",
+		"status":        "#b58900 on #073642 bold",
+		"prompt":        "#eee8d5 on #073642",
+		"selection":     "#002b36 on #268bd2",
+		"link":          "#2aa198 underline",
+		"match":         "#002b36 on #b58900",
+		"match-current": "#002b36 on #cb4b16 bold",
+		"hint":          "#fdf6e3 on #d33682 bold",
+		"scrollbar":     "#586e75",
+		"menu":          "#268bd2 bold",
+		"file":          "#859900",
+		"search":        "#d33682",
+		"binary":        "#b58900",
+		"html":          "#2aa198",
+		"telnet":        "#6c71c4",
+		"error":         "#dc322f",
+	},
+	"monochrome": {
+		"header":        "reverse",
+		"tab":           "reverse",
+		"tab-active":    "bold",
+		"footer":        "reverse",
+		"status":        "reverse bold",
+		"prompt":        "reverse",
+		"selection":     "reverse",
+		"link":          "underline",
+		"match":         "underline bold",
+		"match-current": "reverse bold",
+		"hint":          "reverse bold",
+		"menu":          "underline",
+		"file":          "underline",
+		"search":        "underline",
+		"binary":        "underline",
+		"html":          "underline",
+	},
+}
+
+// themeStyle is a style as written in the configuration, the colors it doesn't set come from the `text` style
+type themeStyle struct {
+	fg, bg                        tcell.Color
+	hasFg, hasBg                  bool
+	bold, dim, underline, reverse bool
+}
+
+// Theme gives the style of each element of the UI, downgraded to the colors supported by the screen
+type Theme struct {
+	specs  map[string]themeStyle
+	styles map[string]tcell.Style
+}
+
+// NewTheme builds the theme of `config`, the styles of its file then its own ones replacing the ones of the built-in theme
+func NewTheme(config ConfigTheme) (*Theme, error) {
+	name := config.Name
+	if name == "" {
+		name = "default"
+	}
+	base, found := themes[name]
+	if !found {
+		return nil, fmt.Errorf("unknown theme `%s`", config.Name)
+	}
+
+	file := map[string]string{}
+	if config.File != "" {
+		content, err := ioutil.ReadFile(config.File)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read theme `%s`: %s", config.File, err)
+		}
+		if err = json.Unmarshal(content, &file); err != nil {
+			return nil, fmt.Errorf("invalid theme `%s`: %s", config.File, err)
+		}
+	}
+
+	theme := &Theme{specs: map[string]themeStyle{}}
+	for _, layer := range []map[string]string{base, file, config.Styles} {
+		for element, spec := range layer {
+			if !isThemeElement(element) {
+				return nil, fmt.Errorf("unknown theme element `%s`", element)
+			}
+			style, err := parseStyle(spec)
+			if err != nil {
+				return nil, fmt.Errorf("%s (for `%s`)", err, element)
+			}
+			theme.specs[element] = style
+		}
+	}
+	theme.SetColors(1 << 24)
+	return theme, nil
+}
+
+func isThemeElement(name string) bool {
+	for _, element := range ThemeElements {
+		if element == name {
+			return true
+		}
+	}
+	return false
+}
+
+// parseStyle reads a style like `yellow on #002b36 bold`: a foreground color, `on` a background color and attributes,
+// colors are names (e.g. `red`), palette numbers (0 to 255), `#rrggbb` or `default`
+func parseStyle(spec string) (themeStyle, error) {
+	style := themeStyle{}
+	words := strings.Fields(strings.ToLower(spec))
+	for i := 0; i < len(words); i++ {
+		switch word := words[i]; word {
+		case "bold":
+			style.bold = true
+		case "dim":
+			style.dim = true
+		case "underline":
+			style.underline = true
+		case "reverse":
+			style.reverse = true
+		case "on":
+			if i+1 >= len(words) {
+				return style, fmt.Errorf("missing background color in `%s`", spec)
+			}
+			i++
+			color, err := parseColor(words[i])
+			if err != nil {
+				return style, err
+			}
+			style.bg, style.hasBg = color, true
+		default:
+			color, err := parseColor(word)
+			if err != nil {
+				return style, err
+			}
+			style.fg, style.hasFg = color, true
+		}
+	}
+	return style, nil
+}
+
+func parseColor(name string) (tcell.Color, error) {
+	if name == "default" {
+		return tcell.ColorDefault, nil
+	}
+	if number, err := strconv.Atoi(name); err == nil && number >= 0 && number < 256 {
+		return tcell.ColorBlack + tcell.Color(number), nil
+	}
+	if color := tcell.GetColor(name); color != tcell.ColorDefault {
+		return color, nil
+	}
+	return tcell.ColorDefault, fmt.Errorf("invalid color `%s`", name)
+}
+
+// SetColors adapts the theme to a screen able to show `colors` colors: colors it doesn't have are replaced by the closest ones,
+// with less than 8 colors only the attributes are kept and the elements with a background are reversed
+func (theme *Theme) SetColors(colors int) {
+	text := theme.specs["text"]
+	theme.styles = map[string]tcell.Style{}
+	for _, element := range ThemeElements {
+		spec := theme.specs[element]
+		fg, bg := text.fg, text.bg
+		if spec.hasFg {
+			fg = spec.fg
+		}
+		if spec.hasBg {
+			bg = spec.bg
+		}
+		reverse := spec.reverse
+		if colors < 8 && spec.hasBg && element != "text" {
+			// Keep bars and highlights visible without their background
+			reverse = !reverse
+		}
+		theme.styles[element] = tcell.StyleDefault.
+			Foreground(downgradeColor(fg, colors)).
+			Background(downgradeColor(bg, colors)).
+			Bold(spec.bold).Dim(spec.dim).Underline(spec.underline).Reverse(reverse)
+	}
+}
+
+// screenColors returns how many colors `screen` shows, terminals announce truecolor support through $COLORTERM
+func screenColors(screen tcell.Screen) int {
+	colors := screen.Colors()
+	if term := os.Getenv("COLORTERM"); colors >= 256 && (term == "truecolor" || term == "24bit") {
+		colors = 1 << 24
+	}
+	return colors
+}
+
+func downgradeColor(color tcell.Color, colors int) tcell.Color {
+	if color == tcell.ColorDefault || colors >= 1<<24 {
+		return color
+	}
+	if colors < 8 {
+		return tcell.ColorDefault
+	}
+	palette := make([]tcell.Color, imin(colors, 256))
+	for i := range palette {
+		palette[i] = tcell.ColorBlack + tcell.Color(i)
+	}
+	if color >= palette[0] && color <= palette[len(palette)-1] {
+		return color
+	}
+	return tcell.FindColor(color, palette)
+}
+
+// Style returns the style of `element`
+func (theme *Theme) Style(element string) tcell.Style {
+	if style, found := theme.styles[element]; found {
+		return style
+	}
+	return theme.styles["text"]
+}
+
+// RecordStyle returns the style of a menu item, depending on its type
+func (theme *Theme) RecordStyle(record *core.Record) tcell.Style {
+	switch {
+	case record.IsBinary():
+		return theme.Style("binary")
+	case record.Type == core.TypeSubMenu:
+		return theme.Style("menu")
+	case record.Type == core.TypeFile:
+		return theme.Style("file")
+	case record.Type == core.TypeSearch || record.Type == core.TypeCCSO:
+		return theme.Style("search")
+	case record.Type == core.TypeHTML:
+		return theme.Style("html")
+	case record.Type == core.TypeTelnet || record.Type == core.TypeTelnet3270:
+		return theme.Style("telnet")
+	case record.Type == core.TypeError:
+		return theme.Style("error")
+	case record.Type == core.TypeInformational:
+		return theme.Style("info")
+	}
+	return theme.Style("text")
+}
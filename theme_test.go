@@ -0,0 +1,126 @@
+package taupe
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/LouisBrunner/taupe/core"
+
+	"github.com/gdamore/tcell"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStyle(t *testing.T) {
+	cases := []struct {
+		input  string
+		output themeStyle
+	}{
+		{"", themeStyle{}},
+		{"red", themeStyle{fg: tcell.ColorRed, hasFg: true}},
+		{"Red on 236 bold", themeStyle{fg: tcell.ColorRed, hasFg: true, bg: tcell.ColorBlack + 236, hasBg: true, bold: true}},
+		{"#ff8000 underline", themeStyle{fg: tcell.NewHexColor(0xff8000), hasFg: true, underline: true}},
+		{"default on navy", themeStyle{fg: tcell.ColorDefault, hasFg: true, bg: tcell.ColorNavy, hasBg: true}},
+		{"reverse dim", themeStyle{reverse: true, dim: true}},
+	}
+	for _, test := range cases {
+		output, err := parseStyle(test.input)
+		if assert.NoError(t, err, "Expected %q to be parsed", test.input) {
+			assert.Equal(t, test.output, output)
+		}
+	}
+}
+
+func TestFailParseStyle(t *testing.T) {
+	cases := []struct {
+		input string
+		err   string
+	}{
+		{"blurple", "invalid color `blurple`"},
+		{"red on", "missing background color in `red on`"},
+		{"256", "invalid color `256`"},
+	}
+	for _, test := range cases {
+		_, err := parseStyle(test.input)
+		if assert.Error(t, err, "Expected %q to fail", test.input) {
+			assert.Equal(t, test.err, err.Error())
+		}
+	}
+}
+
+func TestDowngradeColor(t *testing.T) {
+	cases := []struct {
+		color  tcell.Color
+		colors int
+		output tcell.Color
+	}{
+		{tcell.NewHexColor(0x123456), 1 << 24, tcell.NewHexColor(0x123456)},
+		{tcell.ColorDefault, 8, tcell.ColorDefault},
+		{tcell.ColorRed, 0, tcell.ColorDefault},
+		{tcell.ColorRed, 8, tcell.ColorMaroon},
+		{tcell.ColorBlack + 236, 256, tcell.ColorBlack + 236},
+		{tcell.NewHexColor(0xfe0101), 256, tcell.ColorRed},
+		{tcell.NewHexColor(0x5f87af), 256, tcell.ColorBlack + 67},
+		{tcell.ColorBlack + 67, 16, tcell.ColorGray},
+	}
+	for _, test := range cases {
+		assert.Equal(t, test.output, downgradeColor(test.color, test.colors), "Expected %v with %d colors", test.color, test.colors)
+	}
+}
+
+func TestThemes(t *testing.T) {
+	for name := range themes {
+		_, err := NewTheme(ConfigTheme{Name: name})
+		assert.NoError(t, err, "Expected theme %q to be valid", name)
+	}
+
+	theme, _ := NewTheme(ConfigTheme{Name: "solarized", Styles: map[string]string{"error": "bold"}})
+	fg, bg, attrs := theme.Style("error").Decompose()
+	assert.Equal(t, tcell.NewHexColor(0x839496), fg)
+	assert.Equal(t, tcell.NewHexColor(0x002b36), bg)
+	assert.Equal(t, tcell.AttrBold, attrs)
+
+	theme.SetColors(2)
+	assert.Equal(t, tcell.StyleDefault.Reverse(true), theme.Style("selection"))
+	assert.Equal(t, tcell.StyleDefault, theme.Style("text"))
+
+	assert.Equal(t, theme.Style("menu"), theme.RecordStyle(&core.Record{Type: core.TypeSubMenu}))
+	assert.Equal(t, theme.Style("binary"), theme.RecordStyle(&core.Record{Type: core.TypeGIF}))
+	assert.Equal(t, theme.Style("text"), theme.RecordStyle(&core.Record{Type: core.TypeRedundant}))
+}
+
+func TestThemeFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "taupe")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "theme.json")
+	ioutil.WriteFile(path, []byte(`{"menu": "red", "file": "green"}`), 0644)
+
+	theme, err := NewTheme(ConfigTheme{Name: "monochrome", File: path, Styles: map[string]string{"file": "yellow"}})
+	if assert.NoError(t, err) {
+		fg, _, _ := theme.Style("menu").Decompose()
+		assert.Equal(t, tcell.ColorRed, fg)
+		fg, _, _ = theme.Style("file").Decompose()
+		assert.Equal(t, tcell.ColorYellow, fg)
+	}
+}
+
+func TestFailNewTheme(t *testing.T) {
+	cases := []struct {
+		input ConfigTheme
+		err   string
+	}{
+		{ConfigTheme{Name: "neon"}, "unknown theme `neon`"},
+		{ConfigTheme{Styles: map[string]string{"sidebar": "red"}}, "unknown theme element `sidebar`"},
+		{ConfigTheme{Styles: map[string]string{"menu": "red on"}}, "missing background color in `red on` (for `menu`)"},
+	}
+	for _, test := range cases {
+		_, err := NewTheme(test.input)
+		if assert.Error(t, err, "Expected %v to fail", test.input) {
+			assert.Equal(t, test.err, err.Error())
+		}
+	}
+}
@@ -16,42 +16,153 @@ type uiStatus struct {
 	created time.Time
 }
 
+type uiHistoryEntry struct {
+	address string
+	line    int
+}
+
 type uiHistory struct {
 	wasPrevious bool
-	before      []string
-	after       []string
+	line        int
+	before      []uiHistoryEntry
+	after       []uiHistoryEntry
 }
 
 type uiContent struct {
-	line  int
-	kind  NetworkEventType
-	lines []*core.Record
-	html  []string
+	// line is the selected line of a menu and the first line on screen of text and HTML pages,
+	// offset is the first line on screen of a menu
+	line        int
+	offset      int
+	kind        NetworkEventType
+	streaming   bool
+	lines       []*core.Record
+	warnings    []core.MenuWarning
+	diagnostics bool
+	// diagnosticsOffset is the first warning shown in the diagnostics panel
+	diagnosticsOffset int
+	// source is the HTML of the page, html is how it is laid out for the current width and link its selected link
+	source  string
+	html    *core.HTMLDocument
+	link    int
+	text    []string
+	wrapped []string
+	find    uiFind
 }
 
-// UI represents the ncurses user interface that someone use to interact with the Gophernet
-type UI struct {
-	screen  tcell.Screen
+// uiTab is the state of one page being browsed, each tab has its own content, history and request
+type uiTab struct {
 	address string
+	// name is the title of the page, shown in the tab strip
+	name    string
 	loading bool
-	network NetworkManager
-	request <-chan *NetworkEvent
+	secure  bool
+	cached  bool
+	request uint64
 	content uiContent
-	status  uiStatus
 	history uiHistory
+	form    uiForm
+	plus    func(*core.Attributes)
+	// title is the display text of the item being requested, logged with the visit
+	title string
+	// bookmarkLines maps each line of the bookmarks page to what it shows
+	bookmarkLines []uiBookmarkLine
+}
+
+// UI represents the ncurses user interface that someone use to interact with the Gophernet
+// The embedded uiTab is the visible tab, most of the UI only deals with it
+type UI struct {
+	*uiTab
+	tabs      []*uiTab
+	hidden    bool
+	screen    tcell.Screen
+	config    *Config
+	network   NetworkManager
+	events    <-chan *NetworkEvent
+	downloads map[uint64]*NetworkResultDownload
+	status    uiStatus
+	prompt    uiPrompt
+	hints     uiHints
+	help      uiHelp
+	mouse     uiMouse
+	// zones are the parts of the screen which do something when clicked, set up by render
+	zones    []uiZone
+	inputs   []string
+	searches []string
+	finds    []string
+	commands []string
+	// findRegex and findIgnoreCase are the modes of the searches in pages
+	findRegex      bool
+	findIgnoreCase bool
+	// linkNumbers prefixes the links of menus with their number
+	linkNumbers bool
+	// wrap breaks the lines of text pages which are too long for the screen
+	wrap      bool
+	bookmarks *Bookmarks
+	visits    *History
+	keymap    *Keymap
+	theme     *Theme
+	// actions is uiActions, looked up through the UI as the help and the command palette list and run them
+	actions map[string]uiAction
+	// keys are the keys of a sequence being typed, since keysTime
+	keys     []keyStroke
+	keysTime time.Time
+	// ranKeys are the keys which ran the last action
+	ranKeys []keyStroke
+	// visited is set when pages were logged since the session was saved, at sessionTime
+	visited     bool
+	sessionTime time.Time
+	quit        bool
 }
 
 // NewUI construct a UI correctly initialized
-func NewUI(network NetworkManager) *UI {
-	return &UI{network: network}
+func NewUI(network NetworkManager, config *Config, bookmarks *Bookmarks, visits *History) *UI {
+	tab := &uiTab{}
+	ui := &UI{
+		uiTab:     tab,
+		tabs:      []*uiTab{tab},
+		network:   network,
+		config:    config,
+		bookmarks: bookmarks,
+		visits:    visits,
+		downloads: map[uint64]*NetworkResultDownload{},
+		actions:   uiActions,
+
+		findIgnoreCase: true,
+		linkNumbers:    config.Links.Numbers,
+		wrap:           true,
+	}
+	keymap, err := NewKeymap(config.Keys)
+	if err != nil {
+		ui.fatalError(err)
+	}
+	ui.keymap = keymap
+	theme, err := NewTheme(config.Theme)
+	if err != nil {
+		ui.fatalError(err)
+	}
+	ui.theme = theme
+	return ui
 }
 
 // Run registers the UI with the Network (to get responses) and starts the internal loop
+// An empty `address` opens the bookmarks page
 func (ui *UI) Run(address string) {
+	if address == "" {
+		address = BookmarksAddress
+	}
+	if normalized, err := normalizeAddress(address); err == nil {
+		address = normalized
+	}
 	ui.address = address
+	ui.events = ui.network.Subscribe()
 	ui.run()
 }
 
+// Restore is like Run but reopens the page of the last session, at the same line and with the same back/forward history
+func (ui *UI) Restore() {
+	ui.Run(ui.restoreSession())
+}
+
 func (ui *UI) fatalError(err error) {
 	fmt.Fprintf(os.Stderr, "Fatal Error: %v\n", err)
 	os.Exit(1)
@@ -68,8 +179,12 @@ func (ui *UI) run() {
 	}
 	ui.screen = screen
 	defer screen.Fini()
+	ui.theme.SetColors(screenColors(screen))
 
 	screen.HideCursor()
+	if ui.config.Mouse.Enabled {
+		screen.EnableMouse()
+	}
 	ui.render()
 	ui.refresh()
 
@@ -77,29 +192,44 @@ func (ui *UI) run() {
 	go func() {
 		for {
 			event := screen.PollEvent()
-			uiEvents <- event
-			if ui.isQuitKey(event) {
+			if event == nil {
 				break
 			}
+			uiEvents <- event
 		}
 	}()
 
 out:
 	for {
 		select {
-		case event := <-ui.request:
+		case event := <-ui.events:
 			ui.parseNetworkEvent(event)
 		case event := <-uiEvents:
-			if ui.isQuitKey(event) {
-				break out
-			}
 			switch event := event.(type) {
 			case *tcell.EventResize:
-				ui.render()
+				ui.resize()
+			case *tcell.EventMouse:
+				ui.handleMouse(event)
 			case *tcell.EventKey:
-				ui.handleKey(event)
+				if ui.prompt.enabled {
+					ui.handlePromptKey(event)
+				} else if ui.form.enabled {
+					ui.handleFormKey(event)
+				} else if ui.hints.enabled {
+					ui.handleHintKey(event)
+				} else if ui.help.enabled {
+					ui.handleHelpKey(event)
+				} else {
+					ui.handleKey(event)
+				}
 			}
 		case <-time.After(100 * time.Millisecond):
+			ui.checkKeys()
+			ui.checkSession()
+		}
+		if ui.quit {
+			ui.saveSession()
+			break out
 		}
 		if time.Since(ui.status.created) >= 5*time.Second {
 			ui.status.enabled = false
@@ -113,7 +243,9 @@ func (ui *UI) getContentLength() int {
 	if ui.content.kind == NetworkEventOK {
 		length = len(ui.content.lines)
 	} else if ui.content.kind == NetworkEventHTML {
-		length = len(ui.content.html)
+		length = len(ui.content.html.Lines)
+	} else if ui.content.kind == NetworkEventText {
+		length = len(ui.content.wrapped)
 	}
 	return length
 }
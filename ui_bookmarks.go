@@ -0,0 +1,255 @@
+package taupe
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/LouisBrunner/taupe/core"
+)
+
+// uiBookmarkLine is what a line of the bookmarks page shows: a bookmark of `folder`, or the folder itself when `address` is empty
+// Bookmarks are found by address rather than index, as other tabs may change them
+type uiBookmarkLine struct {
+	folder  string
+	address string
+}
+
+// menu renders the content of `folder` as a Gopher menu, with the matching bookmark of each line
+func (bookmarks *Bookmarks) menu(folder string) ([]*core.Record, []uiBookmarkLine) {
+	title := "Bookmarks"
+	if folder != "" {
+		title = fmt.Sprintf("Bookmarks / %s", folder)
+	}
+	records := []*core.Record{localRecord(core.TypeInformational, title, ""), localRecord(core.TypeInformational, "", "")}
+	lines := []uiBookmarkLine{{}, {}}
+
+	if folder == "" {
+		for _, name := range bookmarks.Folders {
+			records = append(records, localRecord(core.TypeSubMenu, name, bookmarkFolderAddress(name)))
+			lines = append(lines, uiBookmarkLine{folder: name})
+		}
+	}
+	for _, item := range bookmarks.Items {
+		if item.Folder != folder {
+			continue
+		}
+		gtype := core.TypeSubMenu
+		if url, err := core.ParseURL(item.Address); err == nil {
+			gtype = url.Type
+		}
+		records = append(records, localRecord(gtype, item.Title, item.Address))
+		lines = append(lines, uiBookmarkLine{folder: folder, address: item.Address})
+	}
+	if len(records) == 2 {
+		records = append(records, localRecord(core.TypeInformational, "No bookmarks yet, press M on a page to add it", ""))
+		lines = append(lines, uiBookmarkLine{})
+	}
+	return records, lines
+}
+
+func localRecord(gtype core.GopherEntry, display, address string) *core.Record {
+	record, _ := core.ParseRecord(string(gtype) + display)
+	record.Address = address
+	return record
+}
+
+func bookmarkFolderAddress(folder string) string {
+	return BookmarksAddress + "/" + escapeSegment(folder)
+}
+
+func isBookmarksAddress(address string) bool {
+	return address == BookmarksAddress || strings.HasPrefix(address, BookmarksAddress+"/")
+}
+
+func (ui *UI) onBookmarks() bool {
+	return ui.content.kind == NetworkEventOK && isBookmarksAddress(ui.address)
+}
+
+func (ui *UI) showBookmarks(address string) {
+	records, lines := ui.bookmarks.menu(bookmarksFolder(address))
+	ui.name = "Bookmarks"
+	ui.bookmarkLines = lines
+	ui.showLocal(address, records)
+}
+
+func (ui *UI) reloadBookmarks() {
+	if !ui.onBookmarks() {
+		return
+	}
+	ui.content.lines, ui.bookmarkLines = ui.bookmarks.menu(bookmarksFolder(ui.address))
+	ui.content.line = imin(ui.content.line, len(ui.content.lines)-1)
+	if ui.hasSelection() {
+		ui.render()
+	} else {
+		ui.content.line = -1
+		ui.selectLink(1)
+	}
+}
+
+func bookmarksFolder(address string) string {
+	return unescapeSegment(strings.TrimPrefix(strings.TrimPrefix(address, BookmarksAddress), "/"))
+}
+
+// saveBookmarks writes the bookmarks and reloads every tab showing them
+func (ui *UI) saveBookmarks(message string) {
+	if err := ui.bookmarks.Save(); err != nil {
+		ui.setStatus(fmt.Sprintf("Error: %v", err))
+	} else {
+		ui.setStatus(message)
+	}
+	for _, tab := range ui.tabs {
+		ui.inTab(tab, ui.reloadBookmarks)
+	}
+}
+
+// selectedBookmark returns the selected line of the bookmarks page and the index of its bookmark (-1 for a folder)
+func (ui *UI) selectedBookmark() (uiBookmarkLine, int, bool) {
+	if !ui.onBookmarks() || ui.content.line < 0 || ui.content.line >= len(ui.bookmarkLines) {
+		return uiBookmarkLine{}, -1, false
+	}
+	line := ui.bookmarkLines[ui.content.line]
+	if line.address == "" {
+		return line, -1, line.folder != ""
+	}
+	index := ui.bookmarks.indexOf(line.address, line.folder)
+	return line, index, index >= 0
+}
+
+func (ui *UI) addBookmark() {
+	if isBookmarksAddress(ui.address) {
+		ui.setStatus("Error: cannot bookmark the bookmarks")
+		return
+	}
+	address := ui.address
+	ui.openPrompt("Bookmark title", address, nil, func(title string) {
+		title = strings.TrimSpace(title)
+		if title == "" {
+			return
+		}
+		folders := append([]string{}, ui.bookmarks.Folders...)
+		ui.openPrompt("Folder (Up/Down for existing ones, empty for none)", "", &folders, func(folder string) {
+			if err := ui.bookmarks.Add(Bookmark{Title: title, Address: address, Folder: strings.TrimSpace(folder)}); err != nil {
+				ui.setStatus(fmt.Sprintf("Error: %v", err))
+				return
+			}
+			ui.saveBookmarks(fmt.Sprintf("Bookmarked %s", title))
+		})
+	})
+}
+
+func (ui *UI) renameBookmark() {
+	line, index, ok := ui.selectedBookmark()
+	if !ok {
+		ui.setStatus("Error: no bookmark selected")
+		return
+	}
+	if index < 0 {
+		ui.openPrompt("Rename folder", line.folder, nil, func(name string) {
+			if name = strings.TrimSpace(name); name != "" {
+				if err := ui.bookmarks.RenameFolder(line.folder, name); err != nil {
+					ui.setStatus(fmt.Sprintf("Error: %v", err))
+					return
+				}
+				ui.saveBookmarks(fmt.Sprintf("Renamed folder %s to %s", line.folder, name))
+			}
+		})
+		return
+	}
+	ui.openPrompt("Rename bookmark", ui.bookmarks.Items[index].Title, nil, func(title string) {
+		if title = strings.TrimSpace(title); title != "" {
+			ui.bookmarks.Rename(index, title)
+			ui.saveBookmarks(fmt.Sprintf("Renamed bookmark to %s", title))
+		}
+	})
+}
+
+func (ui *UI) deleteBookmark() {
+	line, index, ok := ui.selectedBookmark()
+	if !ok {
+		ui.setStatus("Error: no bookmark selected")
+		return
+	}
+	if index < 0 {
+		count := len(ui.bookmarks.inFolder(line.folder))
+		label := fmt.Sprintf("Delete folder %s and its %d bookmarks? [y/N]", line.folder, count)
+		ui.openPrompt(label, "", nil, func(answer string) {
+			if answer = strings.ToLower(strings.TrimSpace(answer)); answer == "y" || answer == "yes" {
+				ui.bookmarks.DeleteFolder(line.folder)
+				ui.saveBookmarks(fmt.Sprintf("Deleted folder %s", line.folder))
+			}
+		})
+		return
+	}
+	title := ui.bookmarks.Items[index].Title
+	ui.bookmarks.Delete(index)
+	ui.saveBookmarks(fmt.Sprintf("Deleted bookmark %s", title))
+}
+
+func (ui *UI) moveBookmark() {
+	line, index, ok := ui.selectedBookmark()
+	if !ok || index < 0 {
+		ui.setStatus("Error: no bookmark selected")
+		return
+	}
+	folders := append([]string{}, ui.bookmarks.Folders...)
+	ui.openPrompt("Move to folder (Up/Down for existing ones, empty for none)", line.folder, &folders, func(folder string) {
+		folder = strings.TrimSpace(folder)
+		if err := ui.bookmarks.Move(index, folder); err != nil {
+			ui.setStatus(fmt.Sprintf("Error: %v", err))
+			return
+		}
+		ui.saveBookmarks(fmt.Sprintf("Moved %s", ui.bookmarks.Items[index].Title))
+	})
+}
+
+func (ui *UI) createFolder() {
+	ui.openPrompt("New folder", "", nil, func(name string) {
+		if name = strings.TrimSpace(name); name != "" {
+			ui.bookmarks.AddFolder(name)
+			ui.saveBookmarks(fmt.Sprintf("Created folder %s", name))
+		}
+	})
+}
+
+func (ui *UI) exportBookmarks() {
+	initial := filepath.Join(ui.config.DownloadDir, "bookmarks.gophermap")
+	ui.openPrompt("Export to (.gophermap or URL list)", initial, nil, func(input string) {
+		file := expandHome(strings.TrimSpace(input))
+		if file == "" {
+			return
+		}
+		count, err := ui.bookmarks.Export(file)
+		if err != nil {
+			ui.setStatus(fmt.Sprintf("Error: %v", err))
+			return
+		}
+		ui.setStatus(fmt.Sprintf("Exported %d bookmarks to %s", count, file))
+	})
+}
+
+func (ui *UI) importBookmarks() {
+	ui.openPrompt("Import from (.gophermap or URL list)", "", nil, func(input string) {
+		file := expandHome(strings.TrimSpace(input))
+		if file == "" {
+			return
+		}
+		if !isGophermap(file) {
+			ui.doImport(file, "")
+			return
+		}
+		// Gophermaps usually leave out the server of its own items
+		ui.openPrompt("Server of the gophermap (e.g. sdf.org or sdf.org:70, empty if its items give it)", "", nil, func(server string) {
+			ui.doImport(file, strings.TrimSpace(server))
+		})
+	})
+}
+
+func (ui *UI) doImport(file, server string) {
+	added, err := ui.bookmarks.Import(file, server)
+	if err != nil {
+		ui.setStatus(fmt.Sprintf("Error: %v", err))
+		return
+	}
+	ui.saveBookmarks(fmt.Sprintf("Imported %d bookmarks from %s", added, file))
+}
@@ -0,0 +1,266 @@
+package taupe
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/LouisBrunner/taupe/core"
+
+	"github.com/gdamore/tcell"
+)
+
+// uiCommand is a command of the palette, `complete` (if set) lists the possible values of its argument
+type uiCommand struct {
+	args     string
+	help     string
+	run      func(*UI, []string)
+	complete func(*UI) []string
+}
+
+var uiCommands = map[string]uiCommand{
+	"open":     {"<address>", "Open an address", (*UI).openCommandAddress, (*UI).knownAddresses},
+	"tabopen":  {"<address>", "Open an address in a new tab", (*UI).openCommandTab, (*UI).knownAddresses},
+	"save":     {"[file]", "Save the current page", (*UI).savePage, nil},
+	"bookmark": {"", "Bookmark the current page", func(ui *UI, args []string) { ui.addBookmark() }, nil},
+	"set":      {"<option>", "Set an option: `wrap`, `nowrap` or `wrap!` to toggle it", (*UI).setOption, optionNames},
+	"theme":    {"<name>", "Change the colors", (*UI).setTheme, themeNames},
+}
+
+// uiOption is a setting which can be changed with `:set`
+type uiOption struct {
+	get func(*UI) bool
+	set func(*UI, bool)
+}
+
+var uiOptions = map[string]uiOption{
+	"wrap": {func(ui *UI) bool { return ui.wrap }, func(ui *UI, value bool) {
+		ui.wrap = value
+		if ui.content.kind == NetworkEventText {
+			ui.wrapText()
+			ui.scrollText(0)
+		}
+	}},
+	"numbers":    {func(ui *UI) bool { return ui.linkNumbers }, func(ui *UI, value bool) { ui.linkNumbers = value }},
+	"regex":      {func(ui *UI) bool { return ui.findRegex }, func(ui *UI, value bool) { ui.findRegex = value }},
+	"ignorecase": {func(ui *UI) bool { return ui.findIgnoreCase }, func(ui *UI, value bool) { ui.findIgnoreCase = value }},
+	"mouse": {func(ui *UI) bool { return ui.config.Mouse.Enabled }, func(ui *UI, value bool) {
+		ui.config.Mouse.Enabled = value
+		if value {
+			ui.screen.EnableMouse()
+		} else {
+			ui.screen.DisableMouse()
+		}
+	}},
+}
+
+// openCommand opens the command palette, where Tab completes the command and its argument
+func (ui *UI) openCommand() {
+	ui.openPrompt("Command", "", &ui.commands, ui.runCommand)
+	ui.prompt.onKey = func(event *tcell.EventKey) bool {
+		if event.Key() != tcell.KeyTab {
+			return false
+		}
+		ui.completeCommand()
+		ui.render()
+		return true
+	}
+	ui.prompt.onChange = func(string) {
+		ui.prompt.hint = ""
+	}
+	ui.render()
+}
+
+// completeCommand completes the last word of the palette, listing the candidates when there are several of them
+func (ui *UI) completeCommand() {
+	prompt := &ui.prompt
+	text := string(prompt.text[:prompt.cursor])
+	words := strings.Fields(text)
+	if len(words) == 0 || strings.HasSuffix(text, " ") {
+		words = append(words, "")
+	}
+
+	candidates := []string{}
+	if len(words) == 1 {
+		candidates = commandNames()
+	} else if command, found := uiCommands[words[0]]; found && command.complete != nil && len(words) == 2 {
+		candidates = command.complete(ui)
+	}
+	word := words[len(words)-1]
+	completed, matches := completeWord(word, candidates)
+	if len(matches) == 1 {
+		completed += " "
+	}
+	prompt.text = append([]rune(text[:len(text)-len(word)]+completed), prompt.text[prompt.cursor:]...)
+	prompt.cursor = len([]rune(text[:len(text)-len(word)] + completed))
+	prompt.hint = ""
+	if len(matches) > 1 {
+		prompt.hint = strings.Join(matches, " ")
+	}
+}
+
+func commandNames() []string {
+	names := append([]string{}, KeyActions...)
+	for name := range uiCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// runCommand runs a command of the palette, or the action of the same name
+func (ui *UI) runCommand(input string) {
+	words := strings.Fields(input)
+	if len(words) == 0 {
+		return
+	}
+	name, args := words[0], words[1:]
+	if command, found := uiCommands[name]; found {
+		command.run(ui, args)
+		return
+	}
+	if !isKeyAction(name) {
+		ui.setStatus(fmt.Sprintf("Error: unknown command `%s`", name))
+		return
+	}
+	if !ui.isEnabled(name) {
+		ui.setStatus(fmt.Sprintf("Error: `%s` isn't available here", name))
+		return
+	}
+	ui.actions[name].run(ui)
+}
+
+func (ui *UI) commandAddress(args []string) (string, bool) {
+	if len(args) != 1 {
+		ui.setStatus("Error: expected an address")
+		return "", false
+	}
+	address, err := normalizeAddress(args[0])
+	if err != nil {
+		ui.setStatus(fmt.Sprintf("Error: %v", err))
+		return "", false
+	}
+	return address, true
+}
+
+func (ui *UI) openCommandAddress(args []string) {
+	if address, ok := ui.commandAddress(args); ok {
+		ui.doRequest(address)
+	}
+}
+
+func (ui *UI) openCommandTab(args []string) {
+	if address, ok := ui.commandAddress(args); ok {
+		ui.newTab(address)
+	}
+}
+
+// knownAddresses returns the addresses typed before and the bookmarked ones
+func (ui *UI) knownAddresses() []string {
+	addresses := append([]string{}, ui.inputs...)
+	if ui.bookmarks != nil {
+		for _, bookmark := range ui.bookmarks.Items {
+			addresses = append(addresses, bookmark.Address)
+		}
+	}
+	return addresses
+}
+
+// savePage downloads the current page to the given file, asking where when it isn't given
+func (ui *UI) savePage(args []string) {
+	if isLocalAddress(ui.address) {
+		ui.setStatus("Error: cannot save this page")
+		return
+	}
+	record := &core.Record{Address: ui.address, Display: ui.name}
+	if len(args) == 0 {
+		ui.saveAs(record)
+		return
+	}
+	file := expandHome(strings.Join(args, " "))
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(ui.config.DownloadDir, file)
+	}
+	if _, err := os.Stat(file); err == nil {
+		ui.setStatus(fmt.Sprintf("Error: `%s` already exists", file))
+		return
+	}
+	ui.doDownload(ui.address, file)
+}
+
+// setOption changes an option (`wrap`, `nowrap`, `wrap!`), without arguments it shows all of them
+func (ui *UI) setOption(args []string) {
+	if len(args) == 0 {
+		values := []string{}
+		for _, name := range sortedOptions() {
+			if uiOptions[name].get(ui) {
+				values = append(values, name)
+			} else {
+				values = append(values, "no"+name)
+			}
+		}
+		ui.setStatus(strings.Join(values, " "))
+		return
+	}
+	for _, arg := range args {
+		name, value := arg, true
+		option, found := uiOptions[name]
+		if strings.HasSuffix(arg, "!") {
+			name = strings.TrimSuffix(arg, "!")
+			option, found = uiOptions[name]
+			value = found && !option.get(ui)
+		} else if !found && strings.HasPrefix(arg, "no") {
+			name, value = strings.TrimPrefix(arg, "no"), false
+			option, found = uiOptions[name]
+		}
+		if !found {
+			ui.setStatus(fmt.Sprintf("Error: unknown option `%s`", arg))
+			return
+		}
+		option.set(ui, value)
+	}
+	ui.render()
+}
+
+func sortedOptions() []string {
+	names := []string{}
+	for name := range uiOptions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func optionNames(*UI) []string {
+	names := []string{}
+	for _, name := range sortedOptions() {
+		names = append(names, name, "no"+name, name+"!")
+	}
+	return names
+}
+
+func (ui *UI) setTheme(args []string) {
+	if len(args) != 1 {
+		ui.setStatus("Error: expected a theme name")
+		return
+	}
+	theme, err := NewTheme(ConfigTheme{Name: args[0]})
+	if err != nil {
+		ui.setStatus(fmt.Sprintf("Error: %v", err))
+		return
+	}
+	theme.SetColors(screenColors(ui.screen))
+	ui.theme = theme
+	ui.render()
+}
+
+func themeNames(*UI) []string {
+	names := []string{}
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,63 @@
+package taupe
+
+import (
+	"fmt"
+	"strings"
+)
+
+func (ui *UI) toggleDiagnostics() {
+	if len(ui.content.warnings) < 1 {
+		ui.setStatus("Error: no diagnostics for this page")
+		return
+	}
+	ui.content.diagnostics = !ui.content.diagnostics
+	ui.content.diagnosticsOffset = 0
+	ui.render()
+}
+
+// scrollDiagnostics scrolls the diagnostics panel by `diff` warnings, it returns false when the panel isn't shown
+func (ui *UI) scrollDiagnostics(diff int) bool {
+	height := ui.diagnosticsHeight()
+	if height < 1 {
+		return false
+	}
+	last := imax(len(ui.content.warnings)-(height-1), 0)
+	ui.content.diagnosticsOffset = imax(imin(ui.content.diagnosticsOffset+diff, last), 0)
+	ui.render()
+	return true
+}
+
+func (ui *UI) diagnosticsHeight() int {
+	if ui.content.kind != NetworkEventOK || !ui.content.diagnostics || len(ui.content.warnings) < 1 {
+		return 0
+	}
+	_, h := ui.screen.Size()
+	return imin(len(ui.content.warnings)+1, imax((h-2)/3, 2))
+}
+
+func (ui *UI) renderDiagnostics(y int) {
+	height := ui.diagnosticsHeight()
+	if height < 1 {
+		return
+	}
+	w, _ := ui.screen.Size()
+	warnings := ui.content.warnings
+
+	shown := height - 1
+	offset := imax(imin(ui.content.diagnosticsOffset, len(warnings)-shown), 0)
+	title := fmt.Sprintf("Diagnostics: %d warnings (%s to close)", len(warnings), ui.keymap.Key("diagnostics"))
+	if len(warnings) > shown {
+		title = fmt.Sprintf("Diagnostics: warnings %d-%d/%d (%s/%s to scroll, %s to close)", offset+1, offset+shown, len(warnings),
+			ui.keymap.Key("up"), ui.keymap.Key("down"), ui.keymap.Key("diagnostics"))
+	}
+	ui.renderLine(0, y, ljust(title, w), ui.theme.Style("header"))
+
+	for i := 0; i < shown; i++ {
+		warning := warnings[offset+i]
+		text := warning.String()
+		if warning.Source != "" {
+			text = fmt.Sprintf("%s: `%s`", text, strings.Replace(warning.Source, "\t", "\\t", -1))
+		}
+		ui.renderLine(0, y+1+i, text, ui.theme.Style("text"))
+	}
+}
@@ -0,0 +1,95 @@
+package taupe
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/LouisBrunner/taupe/core"
+)
+
+func (ui *UI) saveAs(record *core.Record) {
+	initial := filepath.Join(ui.config.DownloadDir, downloadName(record))
+	ui.openPrompt("Save as", initial, nil, func(input string) {
+		file := expandHome(strings.TrimSpace(input))
+		if file == "" {
+			return
+		}
+		if _, err := os.Stat(file); err == nil {
+			ui.setStatus(fmt.Sprintf("Error: `%s` already exists", file))
+			return
+		}
+		ui.doDownload(record.Address, file)
+	})
+}
+
+func (ui *UI) doDownload(address, file string) {
+	id := ui.network.Download(address, file)
+	ui.downloads[id] = &NetworkResultDownload{Address: address, Path: file}
+	ui.render()
+}
+
+func (ui *UI) parseDownloadEvent(event *NetworkEvent) {
+	switch event.Event {
+	case NetworkEventProgress:
+		ui.downloads[event.ID] = event.ResultDownload
+		ui.render()
+	case NetworkEventDownload:
+		delete(ui.downloads, event.ID)
+		result := event.ResultDownload
+		ui.setStatus(fmt.Sprintf("Saved %s (%s)", result.Path, formatBytes(result.Size)))
+	case NetworkEventError:
+		download := ui.downloads[event.ID]
+		delete(ui.downloads, event.ID)
+		ui.setNetworkError(event.ResultError, func() {
+			ui.doDownload(download.Address, download.Path)
+		})
+	}
+}
+
+func (ui *UI) cancelDownloads() {
+	for id := range ui.downloads {
+		ui.network.Cancel(id)
+		delete(ui.downloads, id)
+	}
+	ui.setStatus("Downloads canceled")
+}
+
+func (ui *UI) downloadStatus() string {
+	if len(ui.downloads) == 1 {
+		for _, progress := range ui.downloads {
+			return fmt.Sprintf(
+				"Downloading %s: %s (%s/s)",
+				filepath.Base(progress.Path), formatBytes(progress.Size), formatBytes(int64(progress.Rate)),
+			)
+		}
+	}
+	size, rate := int64(0), 0.0
+	for _, progress := range ui.downloads {
+		size += progress.Size
+		rate += progress.Rate
+	}
+	return fmt.Sprintf("Downloading %d files: %s (%s/s)", len(ui.downloads), formatBytes(size), formatBytes(int64(rate)))
+}
+
+func downloadName(record *core.Record) string {
+	name := ""
+	if url, err := core.ParseURL(record.Address); err == nil {
+		name = path.Base(url.Selector)
+	}
+	if name == "" || name == "." || name == "/" {
+		name = strings.TrimSpace(record.Display)
+	}
+	name = strings.Map(func(char rune) rune {
+		if char == '/' || char == os.PathSeparator || char < ' ' {
+			return '_'
+		}
+		return char
+	}, name)
+	if name == "" {
+		name = "download"
+	}
+	return name
+}
@@ -1,52 +1,74 @@
 package taupe
 
 import (
-	"github.com/gdamore/tcell"
+	"fmt"
+	"strings"
+
+	"github.com/LouisBrunner/taupe/core"
 )
 
-func (ui *UI) handleKey(event *tcell.EventKey) {
-	if !ui.loading {
-		switch event.Key() {
-		case tcell.KeyRune:
-			switch event.Rune() {
-			case 'r', 'R':
-				ui.refresh()
-			case 'b', 'B':
-				ui.goBack()
-			case 'f', 'F':
-				ui.goForward()
-			case 'i', 'I':
-				ui.input()
-			}
-		case tcell.KeyEnter:
-			ui.requestLine()
-		case tcell.KeyUp:
-			ui.selectLink(-1)
-		case tcell.KeyDown:
-			ui.selectLink(1)
-		case tcell.KeyBackspace:
-			ui.goBack()
-		}
+func (ui *UI) cancel() {
+	ui.network.Cancel(ui.request)
+	ui.loading = false
+	ui.content.streaming = false
+	ui.plus = nil
+	ui.history.wasPrevious = false
+	ui.history.line = 0
+	ui.setStatus("Request canceled")
+}
+
+func (ui *UI) resize() {
+	if ui.content.kind == NetworkEventText {
+		ui.wrapText()
+		ui.scrollText(0)
+	} else if ui.content.kind == NetworkEventHTML {
+		ui.renderHTML()
+		ui.scrollText(0)
+	} else {
+		ui.render()
 	}
 }
 
-func (ui *UI) isQuitKey(event tcell.Event) bool {
-	if event, casted := event.(*tcell.EventKey); casted {
-		switch event.Key() {
-		case tcell.KeyRune:
-			switch event.Rune() {
-			case 'q', 'Q':
-				return true
-			}
-		case tcell.KeyEscape, tcell.KeyCtrlC:
-			return true
-		}
+// moveLine, movePage and moveEdge scroll the diagnostics instead of the page while they are shown
+func (ui *UI) moveLine(diff int) {
+	if ui.scrollDiagnostics(diff) {
+		return
+	}
+	if ui.content.kind == NetworkEventText {
+		ui.scrollText(diff)
+	} else if ui.content.kind == NetworkEventHTML {
+		ui.moveHTML(diff)
+	} else {
+		ui.moveMenu(diff)
 	}
-	return false
+}
+
+func (ui *UI) movePage(diff int) {
+	if ui.scrollDiagnostics(diff * (ui.diagnosticsHeight() - 1)) {
+		return
+	}
+	ui.scroll(diff*ui.pageHeight(), diff > 0)
+}
+
+func (ui *UI) moveEdge(diff int) {
+	if ui.scrollDiagnostics(diff * len(ui.content.warnings)) {
+		return
+	}
+	ui.scroll(diff*ui.getContentLength(), diff < 0)
 }
 
 func (ui *UI) input() {
-	// TODO: manual input
+	ui.openPrompt("Go to", "", &ui.inputs, func(input string) {
+		if strings.TrimSpace(input) == "" {
+			return
+		}
+		address, err := normalizeAddress(input)
+		if err != nil {
+			ui.setStatus(fmt.Sprintf("Error: %v", err))
+			return
+		}
+		ui.doRequest(address)
+	})
 }
 
 func (ui *UI) goBack() {
@@ -57,7 +79,8 @@ func (ui *UI) goBack() {
 	previous := ui.history.before[0]
 	ui.history.before = ui.history.before[1:]
 	ui.history.wasPrevious = true
-	ui.doRequest(previous)
+	ui.history.line = previous.line
+	ui.load(previous.address, CachePrefer)
 }
 
 func (ui *UI) goForward() {
@@ -67,29 +90,69 @@ func (ui *UI) goForward() {
 	}
 	next := ui.history.after[0]
 	ui.history.after = ui.history.after[1:]
-	ui.doRequest(next)
+	ui.history.line = next.line
+	ui.load(next.address, CachePrefer)
 }
 
 func (ui *UI) requestLine() {
-	if ui.content.line < 0 {
+	if link := ui.selectedHTMLLink(); link != nil {
+		ui.followURL(link.URL, link.Text)
+		return
+	}
+	if ui.content.kind != NetworkEventOK || ui.content.line < 0 {
 		ui.setStatus("Error: nothing selectable")
 		return
 	}
 	line := ui.content.lines[ui.content.line]
-	if line.IsLink() {
+	if line.URL != "" {
+		ui.followURL(line.URL, line.Display)
+	} else if line.Ask {
+		ui.ask(line)
+	} else if line.Type == core.TypeSearch {
+		ui.search(line)
+	} else if line.IsBinary() {
+		ui.saveAs(line)
+	} else if line.IsLink() {
+		onHistory := ui.onHistory()
 		ui.doRequest(line.Address)
+		if !onHistory && !isLocalAddress(line.Address) {
+			ui.title = line.Display
+		}
 	} else {
 		ui.setStatus("Error: cannot follow a non-gopher items")
 	}
 }
 
+func (ui *UI) search(record *core.Record) {
+	ui.openPrompt(fmt.Sprintf("Search %s", record.Display), "", &ui.searches, func(query string) {
+		if query == "" {
+			return
+		}
+		url, err := core.ParseURL(record.Address)
+		if err != nil {
+			ui.setStatus(fmt.Sprintf("Error: %v", err))
+			return
+		}
+		url.Search = query
+		ui.doRequest(url.String())
+	})
+}
+
 func (ui *UI) refresh() {
-	ui.doRequest(ui.address)
+	ui.load(ui.address, CacheReload)
+}
+
+func (ui *UI) hasSelection() bool {
+	line := ui.content.line
+	if line < 0 || line >= ui.getContentLength() {
+		return false
+	}
+	return ui.content.kind != NetworkEventOK || ui.content.lines[line].IsLink()
 }
 
 func (ui *UI) selectLink(diff int) {
 	for i := ui.content.line + diff; 0 <= i && i < ui.getContentLength(); i += diff {
-		if ui.content.kind == NetworkEventHTML || ui.content.lines[i].IsLink() {
+		if ui.content.lines[i].IsLink() {
 			ui.content.line = i
 			break
 		}
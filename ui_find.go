@@ -0,0 +1,237 @@
+package taupe
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/LouisBrunner/taupe/core"
+
+	"github.com/gdamore/tcell"
+)
+
+// uiFind is the search in the current page, its matches are kept until the page changes (streaming, resizing, numbering links)
+type uiFind struct {
+	query    string
+	pattern  *regexp.Regexp
+	err      error
+	backward bool
+	current  findMatch
+	matches  []findMatch
+	layout   uiFindLayout
+}
+
+// uiFindLayout is what the lines of a page depend on, the matches are looked up again when it changes
+type uiFindLayout struct {
+	length  int
+	width   int
+	numbers bool
+}
+
+// pageLines returns the lines of the page as they are shown
+func (ui *UI) pageLines() []string {
+	switch ui.content.kind {
+	case NetworkEventOK:
+		return ui.menuLines()
+	case NetworkEventHTML:
+		return ui.content.html.Lines
+	case NetworkEventText:
+		return ui.content.wrapped
+	}
+	return nil
+}
+
+func (ui *UI) isFinding() bool {
+	return ui.content.find.pattern != nil
+}
+
+func (ui *UI) pageMatches() []findMatch {
+	find := &ui.content.find
+	if find.pattern == nil {
+		return nil
+	}
+	w, _ := ui.screen.Size()
+	layout := uiFindLayout{length: ui.getContentLength(), width: w, numbers: ui.linkNumbers}
+	if find.matches == nil || find.layout != layout {
+		find.matches = findMatches(ui.pageLines(), find.pattern)
+		find.layout = layout
+	}
+	return find.matches
+}
+
+// find asks for the text to look for in the page, jumping to the first match while it is typed
+func (ui *UI) find(backward bool) {
+	if ui.pageLines() == nil {
+		ui.setStatus("Error: nothing to search in this page")
+		return
+	}
+	line, offset := ui.content.line, ui.content.offset
+	previous := ui.content.find
+	origin := findMatch{line: line}
+	ui.openPrompt(ui.findLabel(backward), "", &ui.finds, func(query string) {
+		if query == "" {
+			ui.content.find = previous
+			ui.render()
+		}
+	})
+	ui.prompt.onChange = func(query string) {
+		ui.content.line, ui.content.offset = line, offset
+		ui.findInPage(query, backward, origin)
+	}
+	ui.prompt.onCancel = func() {
+		ui.content.line, ui.content.offset = line, offset
+		ui.content.find = previous
+		ui.render()
+	}
+	ui.prompt.onKey = func(event *tcell.EventKey) bool {
+		switch event.Key() {
+		case tcell.KeyCtrlR:
+			ui.findRegex = !ui.findRegex
+		case tcell.KeyCtrlT:
+			ui.findIgnoreCase = !ui.findIgnoreCase
+		default:
+			return false
+		}
+		ui.prompt.label = ui.findLabel(backward)
+		ui.content.line, ui.content.offset = line, offset
+		ui.findInPage(string(ui.prompt.text), backward, origin)
+		ui.render()
+		return true
+	}
+}
+
+func (ui *UI) findLabel(backward bool) string {
+	label := "Find"
+	if backward {
+		label = "Find backward"
+	}
+	regex, ignoreCase := "off", "off"
+	if ui.findRegex {
+		regex = "on"
+	}
+	if ui.findIgnoreCase {
+		ignoreCase = "on"
+	}
+	return fmt.Sprintf("%s (Ctrl+R regex: %s, Ctrl+T ignore case: %s)", label, regex, ignoreCase)
+}
+
+// findInPage looks for `query` in the page and goes to its first match from `from`
+func (ui *UI) findInPage(query string, backward bool, from findMatch) {
+	ui.content.find = uiFind{query: query, backward: backward}
+	ui.prompt.hint = ""
+	if query == "" {
+		return
+	}
+	pattern, err := findPattern(query, ui.findRegex, ui.findIgnoreCase)
+	if err != nil {
+		ui.content.find.err = err
+		ui.prompt.hint = "Invalid pattern"
+		return
+	}
+	ui.content.find.pattern = pattern
+	matches := ui.pageMatches()
+	next := nextMatch(matches, from, backward, !backward)
+	if next < 0 {
+		ui.prompt.hint = "No match"
+		return
+	}
+	ui.showMatch(matches[next])
+	ui.prompt.hint = fmt.Sprintf("%d/%d", next+1, len(matches))
+}
+
+// findNext goes to the next match of the search, in the same direction unless `reverse` is set
+func (ui *UI) findNext(reverse bool) {
+	find := ui.content.find
+	matches := ui.pageMatches()
+	from := find.current
+	if ui.matchIndex(matches) < 0 {
+		from = findMatch{line: ui.content.line}
+	}
+	backward := find.backward != reverse
+	next := nextMatch(matches, from, backward, false)
+	if next < 0 {
+		ui.setStatus(fmt.Sprintf("Error: no match for `%s`", find.query))
+		return
+	}
+	ui.showMatch(matches[next])
+	if matches[next].before(from) != backward {
+		ui.setStatus("Search wrapped")
+	}
+}
+
+// showMatch scrolls the page to `match`, selecting the link of menus closest to it
+func (ui *UI) showMatch(match findMatch) {
+	ui.content.find.current = match
+	if ui.content.kind == NetworkEventOK {
+		if !ui.isLineVisible(match.line) {
+			last := imax(len(ui.content.lines)-ui.pageHeight(), 0)
+			ui.content.offset = imax(imin(match.line-ui.pageHeight()/2, last), 0)
+		}
+		ui.content.line = ui.nearestLink(match.line)
+	} else if match.line < ui.content.line || match.line >= ui.content.line+ui.pageHeight() {
+		last := imax(ui.getContentLength()-ui.pageHeight(), 0)
+		ui.content.line = imax(imin(match.line-ui.pageHeight()/3, last), 0)
+	}
+	ui.render()
+}
+
+// nearestLink returns the link of the menu on screen which is the closest to `line`, -1 if there is none
+func (ui *UI) nearestLink(line int) int {
+	for diff := 0; diff < ui.pageHeight(); diff++ {
+		for _, i := range []int{line - diff, line + diff} {
+			if i >= 0 && i < len(ui.content.lines) && ui.isLineVisible(i) && ui.content.lines[i].IsLink() {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// matchIndex returns the index of the current match in `matches`, -1 if it isn't one of them (e.g. after a resize)
+func (ui *UI) matchIndex(matches []findMatch) int {
+	for i, match := range matches {
+		if match == ui.content.find.current {
+			return i
+		}
+	}
+	return -1
+}
+
+// findStatus describes the search for the footer, e.g. `/gopher 2/5`
+func (ui *UI) findStatus() string {
+	find := ui.content.find
+	if find.query == "" {
+		return ""
+	}
+	prefix := "/"
+	if find.backward {
+		prefix = "?"
+	}
+	if find.err != nil {
+		return fmt.Sprintf("%s%s invalid pattern", prefix, find.query)
+	}
+	matches := ui.pageMatches()
+	if len(matches) == 0 {
+		return fmt.Sprintf("%s%s no match", prefix, find.query)
+	}
+	index := "-"
+	if i := ui.matchIndex(matches); i >= 0 {
+		index = fmt.Sprintf("%d", i+1)
+	}
+	return fmt.Sprintf("%s%s %s/%d", prefix, find.query, index, len(matches))
+}
+
+// renderMatches highlights the matches on screen, the current one with its own style
+func (ui *UI) renderMatches(offset, page int) {
+	lines := ui.pageLines()
+	for _, match := range ui.pageMatches() {
+		if match.line < offset || match.line-offset >= page {
+			continue
+		}
+		style := ui.theme.Style("match")
+		if match == ui.content.find.current {
+			style = ui.theme.Style("match-current")
+		}
+		line := lines[match.line]
+		ui.renderLine(core.StringWidth(line[:match.start]), match.line-offset+1, line[match.start:match.end], style)
+	}
+}
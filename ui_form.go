@@ -0,0 +1,195 @@
+package taupe
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/LouisBrunner/taupe/core"
+
+	"github.com/gdamore/tcell"
+)
+
+type uiFormField struct {
+	core.AskField
+	answer []rune
+	choice int
+}
+
+// uiForm is a Gopher+ +ASK form being filled, its answers are posted to `address`
+type uiForm struct {
+	enabled bool
+	title   string
+	address string
+	fields  []uiFormField
+	current int
+}
+
+func (ui *UI) openForm(record *core.Record, fields []core.AskField) {
+	form := uiForm{enabled: true, title: record.Display, address: record.Address, current: -1}
+	for _, field := range fields {
+		formField := uiFormField{AskField: field}
+		switch field.Kind {
+		case core.AskSelect:
+			if len(field.Choices) > 0 && field.Choices[0] == "1" {
+				formField.choice = 1
+			}
+		case core.AskChoose:
+		default:
+			if len(field.Choices) > 0 {
+				formField.answer = []rune(field.Choices[0])
+			}
+		}
+		form.fields = append(form.fields, formField)
+	}
+	ui.form = form
+	ui.moveField(1)
+}
+
+func (ui *UI) closeForm() {
+	ui.form = uiForm{}
+	ui.screen.HideCursor()
+	ui.render()
+}
+
+func (ui *UI) moveField(diff int) bool {
+	for i := ui.form.current + diff; 0 <= i && i < len(ui.form.fields); i += diff {
+		if ui.form.fields[i].Answerable() {
+			ui.form.current = i
+			ui.render()
+			return true
+		}
+	}
+	ui.render()
+	return false
+}
+
+func (ui *UI) handleFormKey(event *tcell.EventKey) {
+	form := &ui.form
+	if form.current < 0 {
+		if event.Key() == tcell.KeyEnter {
+			ui.submitForm()
+		} else if event.Key() == tcell.KeyEscape || event.Key() == tcell.KeyCtrlC || event.Key() == tcell.KeyCtrlG {
+			ui.closeForm()
+			ui.setStatus("Form canceled")
+		}
+		return
+	}
+	field := &form.fields[form.current]
+
+	switch event.Key() {
+	case tcell.KeyEscape, tcell.KeyCtrlC, tcell.KeyCtrlG:
+		ui.closeForm()
+		ui.setStatus("Form canceled")
+	case tcell.KeyEnter:
+		if !ui.moveField(1) {
+			ui.submitForm()
+		}
+	case tcell.KeyCtrlS:
+		ui.submitForm()
+	case tcell.KeyDown, tcell.KeyTab:
+		ui.moveField(1)
+	case tcell.KeyUp, tcell.KeyBacktab:
+		ui.moveField(-1)
+	case tcell.KeyLeft:
+		ui.changeChoice(field, -1)
+	case tcell.KeyRight:
+		ui.changeChoice(field, 1)
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if !field.isChoice() && len(field.answer) > 0 {
+			field.answer = field.answer[:len(field.answer)-1]
+		}
+	case tcell.KeyCtrlU:
+		if !field.isChoice() {
+			field.answer = nil
+		}
+	case tcell.KeyRune:
+		if field.Kind == core.AskSelect && event.Rune() == ' ' {
+			field.choice = 1 - field.choice
+		} else if !field.isChoice() {
+			field.answer = append(field.answer, event.Rune())
+		}
+	}
+	ui.render()
+}
+
+func (field *uiFormField) isChoice() bool {
+	return field.Kind == core.AskSelect || field.Kind == core.AskChoose
+}
+
+func (ui *UI) changeChoice(field *uiFormField, diff int) {
+	switch field.Kind {
+	case core.AskSelect:
+		field.choice = 1 - field.choice
+	case core.AskChoose:
+		if count := len(field.Choices); count > 0 {
+			field.choice = (field.choice + diff + count) % count
+		}
+	}
+}
+
+func (field *uiFormField) value() string {
+	switch field.Kind {
+	case core.AskSelect:
+		return fmt.Sprintf("%d", field.choice)
+	case core.AskChoose:
+		if field.choice < len(field.Choices) {
+			return field.Choices[field.choice]
+		}
+		return ""
+	}
+	return string(field.answer)
+}
+
+func (field *uiFormField) display() string {
+	switch field.Kind {
+	case core.AskNote:
+		return field.Prompt
+	case core.AskSelect:
+		mark := " "
+		if field.choice == 1 {
+			mark = "x"
+		}
+		return fmt.Sprintf("[%s] %s", mark, field.Prompt)
+	case core.AskChoose:
+		return fmt.Sprintf("%s < %s >", field.Prompt, field.value())
+	case core.AskPassword:
+		return fmt.Sprintf("%s %s", field.Prompt, strings.Repeat("*", len(field.answer)))
+	}
+	return fmt.Sprintf("%s %s", field.Prompt, string(field.answer))
+}
+
+func (ui *UI) submitForm() {
+	answers := []string{}
+	for i := range ui.form.fields {
+		if field := &ui.form.fields[i]; field.Answerable() {
+			answers = append(answers, field.value())
+		}
+	}
+	address := ui.form.address
+	ui.closeForm()
+	ui.doPost(address, answers)
+}
+
+func (ui *UI) renderForm(page int, style tcell.Style) {
+	w, _ := ui.screen.Size()
+	form := ui.form
+	ui.screen.HideCursor()
+	ui.renderLine(0, 1, fmt.Sprintf("Form: %s", form.title), style.Bold(true))
+
+	offset := imax(form.current-page+3, 0)
+	for i := offset; i-offset < page-2 && i < len(form.fields); i++ {
+		field := &form.fields[i]
+		line := field.display()
+		fieldStyle := style
+		if field.Answerable() {
+			fieldStyle = fieldStyle.Underline(true)
+		}
+		if i == form.current {
+			fieldStyle = ui.theme.Style("selection")
+			if !field.isChoice() {
+				ui.screen.ShowCursor(imin(core.StringWidth(line), w-1), i-offset+3)
+			}
+		}
+		ui.renderLine(0, i-offset+3, line, fieldStyle)
+	}
+}
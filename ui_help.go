@@ -0,0 +1,124 @@
+package taupe
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell"
+)
+
+// helpKeysWidth is the width of the column of keys in the help
+const helpKeysWidth = 24
+
+// uiHelp is the help screen, listing the keys of the current page first
+type uiHelp struct {
+	enabled bool
+	lines   []string
+	offset  int
+}
+
+// showHelp lists every action and its keys (the ones of the current page first) then the commands
+func (ui *UI) showHelp() {
+	lines := []string{}
+	section := func(title string, actions []string) {
+		if len(actions) == 0 {
+			return
+		}
+		lines = append(lines, title)
+		for _, action := range actions {
+			keys := strings.Join(ui.keymap.Keys(action), ", ")
+			if keys == "" {
+				keys = "(none)"
+			}
+			lines = append(lines, fmt.Sprintf("  %s %s", ljust(keys, helpKeysWidth), actionHelp[action]))
+		}
+		lines = append(lines, "")
+	}
+
+	page, everywhere, elsewhere := []string{}, []string{}, []string{}
+	for _, action := range KeyActions {
+		if ui.actions[action].enabled == nil {
+			everywhere = append(everywhere, action)
+		} else if ui.isEnabled(action) {
+			page = append(page, action)
+		} else {
+			elsewhere = append(elsewhere, action)
+		}
+	}
+	section("On this page", page)
+	section("Everywhere", everywhere)
+	section("On other pages", elsewhere)
+
+	lines = append(lines, fmt.Sprintf("Commands (%s then Tab to complete)", ui.keymap.Key("command")))
+	names := []string{}
+	for name := range uiCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		command := uiCommands[name]
+		lines = append(lines, fmt.Sprintf("  %s %s", ljust(strings.TrimSpace(":"+name+" "+command.args), helpKeysWidth), command.help))
+	}
+	lines = append(lines, fmt.Sprintf("  %s %s", ljust(":<action>", helpKeysWidth), "Run any action above by name, e.g. :diagnostics"))
+	lines = append(lines, "", "In the find prompt", fmt.Sprintf("  %s %s", ljust("Ctrl+R, Ctrl+T", helpKeysWidth), "Toggle regex, toggle ignoring case"))
+
+	ui.help = uiHelp{enabled: true, lines: lines}
+	ui.render()
+}
+
+func (ui *UI) closeHelp() {
+	ui.help = uiHelp{}
+	ui.render()
+}
+
+func (ui *UI) scrollHelp(diff int) {
+	last := imax(len(ui.help.lines)-ui.pageHeight(), 0)
+	ui.help.offset = imax(imin(ui.help.offset+diff, last), 0)
+	ui.render()
+}
+
+// handleHelpKey scrolls the help, any other key closes it
+func (ui *UI) handleHelpKey(event *tcell.EventKey) {
+	page := ui.pageHeight()
+	switch event.Key() {
+	case tcell.KeyUp:
+		ui.scrollHelp(-1)
+	case tcell.KeyDown:
+		ui.scrollHelp(1)
+	case tcell.KeyPgUp:
+		ui.scrollHelp(-page)
+	case tcell.KeyPgDn:
+		ui.scrollHelp(page)
+	case tcell.KeyHome:
+		ui.scrollHelp(-len(ui.help.lines))
+	case tcell.KeyEnd:
+		ui.scrollHelp(len(ui.help.lines))
+	case tcell.KeyRune:
+		switch event.Rune() {
+		case 'k':
+			ui.scrollHelp(-1)
+		case 'j':
+			ui.scrollHelp(1)
+		case ' ':
+			ui.scrollHelp(page)
+		default:
+			ui.closeHelp()
+		}
+	default:
+		ui.closeHelp()
+	}
+}
+
+func (ui *UI) renderHelp(page int) {
+	title := ui.theme.Style("menu")
+	text := ui.theme.Style("text")
+	for i := ui.help.offset; i-ui.help.offset < page && i < len(ui.help.lines); i++ {
+		line := ui.help.lines[i]
+		style := text
+		if line != "" && !strings.HasPrefix(line, " ") {
+			style = title
+		}
+		ui.renderLine(0, i-ui.help.offset+1, line, style)
+	}
+}
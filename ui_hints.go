@@ -0,0 +1,194 @@
+package taupe
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/LouisBrunner/taupe/core"
+
+	"github.com/gdamore/tcell"
+)
+
+// hintAlphabet are the characters of the hint labels, the easiest to type first
+const hintAlphabet = "asdfghjklqwertyuiopzxcvbnm"
+
+// uiHint labels a link on screen: a menu item or, when `link` isn't -1, a link of an HTML page
+type uiHint struct {
+	label string
+	line  int
+	link  int
+}
+
+// uiHints is the hint mode, where typing the label of a link on screen jumps to it
+type uiHints struct {
+	enabled bool
+	hints   []uiHint
+	typed   string
+}
+
+func (ui *UI) hasLinkNumbers() bool {
+	return ui.content.kind == NetworkEventHTML || (ui.content.kind == NetworkEventOK && ui.linkNumbers)
+}
+
+func (ui *UI) toggleLinkNumbers() {
+	ui.linkNumbers = !ui.linkNumbers
+	ui.render()
+}
+
+// askLinkNumber asks for the number of the link to jump to, starting with the digit just typed
+func (ui *UI) askLinkNumber() {
+	initial := ""
+	if len(ui.ranKeys) == 1 && unicode.IsDigit(ui.ranKeys[0].char) {
+		initial = string(ui.ranKeys[0].char)
+	}
+	ui.openPrompt("Link number", initial, nil, func(input string) {
+		input = strings.TrimSpace(input)
+		if input == "" {
+			return
+		}
+		number, err := strconv.Atoi(input)
+		if err != nil {
+			ui.setStatus(fmt.Sprintf("Error: invalid link number `%s`", input))
+			return
+		}
+		ui.jumpToNumber(number)
+	})
+}
+
+// jumpToNumber selects the link numbered `number` and follows it if the configuration says so
+func (ui *UI) jumpToNumber(number int) {
+	if ui.content.kind == NetworkEventHTML {
+		if number < 1 || number > len(ui.content.html.Links) {
+			ui.setStatus(fmt.Sprintf("Error: no link %d", number))
+			return
+		}
+		ui.jumpToLink(uiHint{line: ui.content.html.Links[number-1].Line, link: number - 1}, ui.config.Links.Follow)
+		return
+	}
+	count := 0
+	for i, line := range ui.content.lines {
+		if !line.IsLink() {
+			continue
+		}
+		count++
+		if count == number {
+			ui.jumpToLink(uiHint{line: i, link: -1}, ui.config.Links.Follow)
+			return
+		}
+	}
+	ui.setStatus(fmt.Sprintf("Error: no link %d", number))
+}
+
+// jumpToLink selects the link of `hint`, scrolling to it, then follows it when `follow` is set
+func (ui *UI) jumpToLink(hint uiHint, follow bool) {
+	if hint.link < 0 {
+		ui.content.line = hint.line
+	} else {
+		ui.content.link = hint.link
+		if !ui.isLinkVisible(ui.content.html.Links[hint.link]) {
+			last := imax(ui.getContentLength()-ui.pageHeight(), 0)
+			ui.content.line = imax(imin(hint.line-ui.pageHeight()/3, last), 0)
+		}
+	}
+	if follow {
+		ui.requestLine()
+	} else {
+		ui.render()
+	}
+}
+
+// showHints labels the links on screen
+func (ui *UI) showHints() {
+	offset := ui.pageOffset()
+	page := ui.pageHeight()
+	hints := []uiHint{}
+	if ui.content.kind == NetworkEventOK {
+		for i := offset; i-offset < page && i < len(ui.content.lines); i++ {
+			if ui.content.lines[i].IsLink() {
+				hints = append(hints, uiHint{line: i, link: -1})
+			}
+		}
+	} else if ui.content.kind == NetworkEventHTML {
+		for i, link := range ui.content.html.Links {
+			if ui.isLinkVisible(link) {
+				hints = append(hints, uiHint{line: link.Line, link: i})
+			}
+		}
+	}
+	if len(hints) == 0 {
+		ui.setStatus("Error: no link on screen")
+		return
+	}
+	for i, label := range hintLabels(len(hints), hintAlphabet) {
+		hints[i].label = label
+	}
+	ui.hints = uiHints{enabled: true, hints: hints}
+	ui.render()
+}
+
+func (ui *UI) closeHints() {
+	ui.hints = uiHints{}
+	ui.render()
+}
+
+// handleHintKey narrows the hints down to the ones starting with the typed characters, an uppercase one selecting the link without following it
+func (ui *UI) handleHintKey(event *tcell.EventKey) {
+	hints := &ui.hints
+	switch event.Key() {
+	case tcell.KeyEscape, tcell.KeyCtrlC, tcell.KeyCtrlG:
+		ui.closeHints()
+		return
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(hints.typed) > 0 {
+			hints.typed = hints.typed[:len(hints.typed)-1]
+		}
+		ui.render()
+		return
+	case tcell.KeyRune:
+	default:
+		return
+	}
+
+	char := event.Rune()
+	hints.typed += string(unicode.ToLower(char))
+	remaining := 0
+	for _, hint := range hints.hints {
+		if hint.label == hints.typed {
+			ui.closeHints()
+			ui.jumpToLink(hint, !unicode.IsUpper(char))
+			return
+		}
+		if strings.HasPrefix(hint.label, hints.typed) {
+			remaining++
+		}
+	}
+	if remaining == 0 {
+		typed := hints.typed
+		ui.closeHints()
+		ui.setStatus(fmt.Sprintf("Error: no hint `%s`", typed))
+		return
+	}
+	ui.render()
+}
+
+// renderHints draws the labels of the hints still matching what was typed, over the start of their link
+func (ui *UI) renderHints(offset int) {
+	if !ui.hints.enabled {
+		return
+	}
+	for _, hint := range ui.hints.hints {
+		// The page may have changed since the hints were shown
+		if !strings.HasPrefix(hint.label, ui.hints.typed) || hint.line >= ui.getContentLength() ||
+			(hint.link >= 0 && (ui.content.kind != NetworkEventHTML || hint.link >= len(ui.content.html.Links))) {
+			continue
+		}
+		x := 0
+		if hint.link >= 0 {
+			link := ui.content.html.Links[hint.link]
+			x = core.StringWidth(ui.content.html.Lines[link.Line][:link.Column])
+		}
+		ui.renderLine(x, hint.line-offset+1, hint.label[len(ui.hints.typed):], ui.theme.Style("hint"))
+	}
+}
@@ -0,0 +1,159 @@
+package taupe
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/LouisBrunner/taupe/core"
+)
+
+// sessionDepth is how many back/forward entries are kept in the saved session
+const sessionDepth = 100
+
+// sessionInterval is how often new visits are written to the history file, it is always written when quitting
+const sessionInterval = 30 * time.Second
+
+func isHistoryAddress(address string) bool {
+	return address == HistoryAddress || strings.HasPrefix(address, HistoryAddress+"/")
+}
+
+// isLocalAddress returns if `address` is a page generated by taupe itself (bookmarks, history)
+func isLocalAddress(address string) bool {
+	return isBookmarksAddress(address) || isHistoryAddress(address)
+}
+
+func (ui *UI) onHistory() bool {
+	return ui.content.kind == NetworkEventOK && isHistoryAddress(ui.address)
+}
+
+// showLocal displays a page generated by taupe itself like any other menu, without going through the network
+func (ui *UI) showLocal(address string, records []*core.Record) {
+	if ui.loading {
+		ui.network.Cancel(ui.request)
+		ui.loading = false
+		ui.content.streaming = false
+	}
+	ui.plus = nil
+	ui.secure = false
+	ui.cached = false
+	ui.parseMenu(&NetworkResult{Address: address, Records: records}, true)
+	ui.history.wasPrevious = false
+	ui.history.line = 0
+}
+
+func (ui *UI) showHistory(address string) {
+	ui.name = "History"
+	ui.showLocal(address, ui.visits.menu(historyQuery(address)))
+}
+
+func (ui *UI) searchHistory() {
+	ui.openPrompt("Search history", historyQuery(ui.address), &ui.searches, func(query string) {
+		ui.doRequest(historySearchAddress(strings.TrimSpace(query)))
+	})
+}
+
+// logVisit adds the page which was just loaded to the history, it is saved by checkSession
+func (ui *UI) logVisit() {
+	title := ui.title
+	ui.title = ""
+	if isLocalAddress(ui.address) {
+		return
+	}
+	if title == "" {
+		title = ui.visits.Title(ui.address)
+	}
+	if title == "" {
+		title = ui.address
+	}
+	ui.name = title
+	ui.visits.Add(Visit{Address: ui.address, Title: title, Type: string(visitType(ui.address)), Time: time.Now()})
+	ui.visited = true
+}
+
+// checkSession saves the session if pages were visited since it was last saved, at most every sessionInterval
+func (ui *UI) checkSession() {
+	if ui.visited && time.Since(ui.sessionTime) >= sessionInterval {
+		ui.saveSession()
+	}
+}
+
+func (ui *UI) saveSession() {
+	convert := func(entries []uiHistoryEntry) []SessionEntry {
+		result := []SessionEntry{}
+		for i := 0; i < len(entries) && i < sessionDepth; i++ {
+			result = append(result, SessionEntry{Address: entries[i].address, Line: entries[i].line})
+		}
+		return result
+	}
+	ui.visits.Session = Session{
+		SessionEntry: SessionEntry{Address: ui.address, Line: ui.content.line},
+		Before:       convert(ui.history.before),
+		After:        convert(ui.history.after),
+	}
+	ui.visited = false
+	ui.sessionTime = time.Now()
+	if err := ui.visits.Save(); err != nil {
+		ui.setStatus(fmt.Sprintf("Error: %v", err))
+	}
+}
+
+func (ui *UI) restoreSession() string {
+	convert := func(entries []SessionEntry) []uiHistoryEntry {
+		result := []uiHistoryEntry{}
+		for _, entry := range entries {
+			result = append(result, uiHistoryEntry{address: entry.Address, line: entry.Line})
+		}
+		return result
+	}
+	session := ui.visits.Session
+	ui.history.before = convert(session.Before)
+	ui.history.after = convert(session.After)
+	ui.history.line = session.Line
+	return session.Address
+}
+
+func visitType(address string) core.GopherEntry {
+	url, err := core.ParseURL(address)
+	if err != nil {
+		return core.TypeSubMenu
+	}
+	if url.Type == core.TypeSearch && url.Search != "" {
+		return core.TypeSubMenu
+	}
+	return url.Type
+}
+
+// menu renders the visits matching `query` as a Gopher menu
+func (history *History) menu(query string) []*core.Record {
+	title := "History"
+	if query != "" {
+		title = fmt.Sprintf("History matching `%s`", query)
+	}
+	records := []*core.Record{localRecord(core.TypeInformational, title, ""), localRecord(core.TypeInformational, "", "")}
+
+	visits := history.Search(query)
+	for _, visit := range visits {
+		display := fmt.Sprintf("%s  %s", visit.Time.Local().Format("2006-01-02 15:04"), visit.Title)
+		gtype := core.TypeSubMenu
+		if visit.Type != "" {
+			gtype = core.ParseEntry(visit.Type[0])
+		}
+		records = append(records, localRecord(gtype, display, visit.Address))
+	}
+	if len(visits) == 0 {
+		records = append(records, localRecord(core.TypeInformational, "No page found", ""))
+	}
+	return records
+}
+
+func historyQuery(address string) string {
+	return unescapeSegment(strings.TrimPrefix(strings.TrimPrefix(address, HistoryAddress), "/"))
+}
+
+func historySearchAddress(query string) string {
+	if query == "" {
+		return HistoryAddress
+	}
+	return HistoryAddress + "/" + escapeSegment(query)
+}
@@ -0,0 +1,100 @@
+package taupe
+
+import (
+	"fmt"
+	"net/url"
+	"os/exec"
+
+	"github.com/LouisBrunner/taupe/core"
+)
+
+// renderHTML lays out the HTML page for the current width of the screen, less the last column when the scrollbar is shown
+func (ui *UI) renderHTML() {
+	w, _ := ui.screen.Size()
+	ui.content.html = core.RenderHTML(ui.content.source, w)
+	if len(ui.content.html.Lines) > ui.pageHeight() {
+		ui.content.html = core.RenderHTML(ui.content.source, w-1)
+	}
+	if ui.content.link >= len(ui.content.html.Links) {
+		ui.content.link = -1
+	}
+}
+
+func (ui *UI) selectedHTMLLink() *core.HTMLLink {
+	if ui.content.kind != NetworkEventHTML || ui.content.link < 0 {
+		return nil
+	}
+	return ui.content.html.Links[ui.content.link]
+}
+
+func (ui *UI) isLinkVisible(link *core.HTMLLink) bool {
+	return ui.content.line <= link.Line && link.Line < ui.content.line+ui.pageHeight()
+}
+
+// moveHTML selects the next (or previous) link when it is on screen and scrolls the page otherwise
+func (ui *UI) moveHTML(diff int) {
+	next := ui.content.link + diff
+	if next >= 0 && next < len(ui.content.html.Links) && ui.isLinkVisible(ui.content.html.Links[next]) {
+		ui.content.link = next
+		ui.render()
+		return
+	}
+	ui.scrollText(diff)
+	if link := ui.selectedHTMLLink(); link != nil && !ui.isLinkVisible(link) {
+		ui.content.link = -1
+		ui.render()
+	}
+}
+
+// followURL opens gopher URLs in taupe (logging `title` with the visit) and hands all the others to the external opener,
+// relative links are resolved against the current page
+func (ui *UI) followURL(target, title string) {
+	target, err := resolveLink(ui.address, target)
+	if err != nil {
+		ui.setStatus(fmt.Sprintf("Error: %v", err))
+		return
+	}
+	parsed, err := url.Parse(target)
+	if err != nil {
+		ui.setStatus(fmt.Sprintf("Error: %v", err))
+		return
+	}
+	if parsed.Scheme == "gopher" || parsed.Scheme == "gophers" {
+		address, err := normalizeAddress(target)
+		if err != nil {
+			ui.setStatus(fmt.Sprintf("Error: %v", err))
+			return
+		}
+		ui.doRequest(address)
+		ui.title = title
+		return
+	}
+	ui.openExternal(target)
+}
+
+// selectedURL returns the external target of the selected menu item or HTML link, if any
+func (ui *UI) selectedURL() string {
+	if link := ui.selectedHTMLLink(); link != nil {
+		return link.URL
+	}
+	if ui.content.kind != NetworkEventOK || ui.content.line < 0 || ui.content.line >= len(ui.content.lines) {
+		return ""
+	}
+	return ui.content.lines[ui.content.line].URL
+}
+
+// openExternal opens `address` with the command set in the configuration (e.g. a web browser)
+func (ui *UI) openExternal(address string) {
+	args := openerCommand(ui.config.Opener, address)
+	if len(args) == 0 {
+		ui.setStatus(fmt.Sprintf("Error: no program configured to open `%s`", address))
+		return
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	if err := cmd.Start(); err != nil {
+		ui.setStatus(fmt.Sprintf("Error: cannot open `%s`: %s", address, err))
+		return
+	}
+	go cmd.Wait()
+	ui.setStatus(fmt.Sprintf("Opened %s with %s", address, args[0]))
+}
@@ -0,0 +1,162 @@
+package taupe
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell"
+)
+
+// keyTimeout is how long the UI waits for the next key of a sequence when the keys typed so far already run an action
+const keyTimeout = time.Second
+
+// uiAction is what the keys bound to an action do, `enabled` (if set) tells if the action applies in the current context
+type uiAction struct {
+	enabled func(*UI) bool
+	run     func(*UI)
+}
+
+// actionHelp describes each action in the footer
+var actionHelp = map[string]string{
+	"find-next":        "Next match",
+	"find-previous":    "Previous match",
+	"search-history":   "Search",
+	"rename-bookmark":  "Name",
+	"delete-bookmark":  "Delete",
+	"move-bookmark":    "Move",
+	"create-folder":    "Create folder",
+	"export-bookmarks": "Export",
+	"import-bookmarks": "Import",
+	"cancel":           "Cancel",
+	"cancel-downloads": "Cancel downloads",
+	"up":               "Up",
+	"down":             "Down",
+	"scroll-up":        "Scroll up",
+	"scroll-down":      "Scroll down",
+	"page-up":          "Page up",
+	"page-down":        "Page down",
+	"top":              "Top",
+	"bottom":           "Bottom",
+	"open":             "Open",
+	"find":             "Find",
+	"link-number":      "Link number",
+	"link-numbers":     "Numbers",
+	"hints":            "Hints",
+	"find-backward":    "Find backward",
+	"back":             "Back",
+	"forward":          "Forward",
+	"refresh":          "Refresh",
+	"input":            "Go to",
+	"bookmark":         "Mark",
+	"bookmarks":        "Bookmarks",
+	"history":          "History",
+	"attributes":       "Attributes",
+	"views":            "Views",
+	"diagnostics":      "Diagnostics",
+	"help":             "Help",
+	"command":          "Command",
+	"open-tab":         "Open in tab",
+	"new-tab":          "New tab",
+	"close-tab":        "Close tab",
+	"next-tab":         "Next tab",
+	"previous-tab":     "Previous tab",
+	"quit":             "Quit",
+}
+
+var uiActions = map[string]uiAction{
+	"find-next":        {(*UI).isFinding, func(ui *UI) { ui.findNext(false) }},
+	"find-previous":    {(*UI).isFinding, func(ui *UI) { ui.findNext(true) }},
+	"search-history":   {(*UI).onHistory, (*UI).searchHistory},
+	"rename-bookmark":  {(*UI).onBookmarks, (*UI).renameBookmark},
+	"delete-bookmark":  {(*UI).onBookmarks, (*UI).deleteBookmark},
+	"move-bookmark":    {(*UI).onBookmarks, (*UI).moveBookmark},
+	"create-folder":    {(*UI).onBookmarks, (*UI).createFolder},
+	"export-bookmarks": {(*UI).onBookmarks, (*UI).exportBookmarks},
+	"import-bookmarks": {(*UI).onBookmarks, (*UI).importBookmarks},
+	"cancel":           {func(ui *UI) bool { return ui.loading }, (*UI).cancel},
+	"cancel-downloads": {func(ui *UI) bool { return len(ui.downloads) > 0 }, (*UI).cancelDownloads},
+	"up":               {nil, func(ui *UI) { ui.moveLine(-1) }},
+	"down":             {nil, func(ui *UI) { ui.moveLine(1) }},
+	"scroll-up":        {nil, func(ui *UI) { ui.scrollLines(-1) }},
+	"scroll-down":      {nil, func(ui *UI) { ui.scrollLines(1) }},
+	"page-up":          {nil, func(ui *UI) { ui.movePage(-1) }},
+	"page-down":        {nil, func(ui *UI) { ui.movePage(1) }},
+	"top":              {nil, func(ui *UI) { ui.moveEdge(-1) }},
+	"bottom":           {nil, func(ui *UI) { ui.moveEdge(1) }},
+	"open":             {nil, (*UI).requestLine},
+	"find":             {nil, func(ui *UI) { ui.find(false) }},
+	"link-number":      {(*UI).hasLinkNumbers, (*UI).askLinkNumber},
+	"link-numbers":     {nil, (*UI).toggleLinkNumbers},
+	"hints":            {nil, (*UI).showHints},
+	"find-backward":    {nil, func(ui *UI) { ui.find(true) }},
+	"back":             {nil, (*UI).goBack},
+	"forward":          {nil, (*UI).goForward},
+	"refresh":          {nil, (*UI).refresh},
+	"input":            {nil, (*UI).input},
+	"bookmark":         {nil, (*UI).addBookmark},
+	"bookmarks":        {nil, func(ui *UI) { ui.doRequest(BookmarksAddress) }},
+	"history":          {nil, func(ui *UI) { ui.doRequest(HistoryAddress) }},
+	"attributes":       {nil, (*UI).inspect},
+	"views":            {nil, (*UI).selectView},
+	"diagnostics":      {nil, (*UI).toggleDiagnostics},
+	"help":             {nil, (*UI).showHelp},
+	"command":          {nil, (*UI).openCommand},
+	"open-tab":         {nil, (*UI).openInTab},
+	"new-tab":          {nil, func(ui *UI) { ui.newTab(BookmarksAddress) }},
+	"close-tab":        {nil, (*UI).closeTab},
+	"next-tab":         {nil, func(ui *UI) { ui.cycleTab(1) }},
+	"previous-tab":     {nil, func(ui *UI) { ui.cycleTab(-1) }},
+	"quit":             {nil, func(ui *UI) { ui.quit = true }},
+}
+
+func (ui *UI) isEnabled(action string) bool {
+	enabled := ui.actions[action].enabled
+	return enabled == nil || enabled(ui)
+}
+
+// handleKey runs the action bound to the keys typed so far, waiting for the next one if they start a longer sequence
+func (ui *UI) handleKey(event *tcell.EventKey) {
+	ui.keys = append(ui.keys, newKeyStroke(event))
+	ui.keysTime = time.Now()
+	ui.runKeys(false)
+}
+
+// runKeys runs the action bound to the pending keys, when `timeout` is set it doesn't wait for a longer sequence anymore
+func (ui *UI) runKeys(timeout bool) {
+	actions, longer := ui.keymap.match(ui.keys, ui.isEnabled)
+	if longer && !timeout {
+		ui.render()
+		return
+	}
+	keys := ui.keys
+	ui.keys = nil
+	if len(actions) > 0 {
+		ui.ranKeys = keys
+		ui.actions[actions[0]].run(ui)
+	} else if len(keys) > 1 {
+		// The last key may start a new sequence
+		ui.keys = keys[len(keys)-1:]
+		ui.runKeys(timeout)
+	} else {
+		ui.render()
+	}
+}
+
+// checkKeys gives up waiting for the next key of a sequence after a while
+func (ui *UI) checkKeys() {
+	if len(ui.keys) > 0 && time.Since(ui.keysTime) >= keyTimeout {
+		ui.runKeys(true)
+	}
+}
+
+// keyHelp describes the keys of `actions` for the footer, e.g. `q:Quit r:Refresh`
+func (ui *UI) keyHelp(actions ...string) string {
+	parts := []string{}
+	for _, action := range actions {
+		if key := ui.keymap.Key(action); key != "" {
+			parts = append(parts, fmt.Sprintf("%s:%s", key, actionHelp[action]))
+		}
+	}
+	return strings.Join(parts, " ")
+}
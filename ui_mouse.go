@@ -0,0 +1,140 @@
+package taupe
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/LouisBrunner/taupe/core"
+
+	"github.com/gdamore/tcell"
+)
+
+// doubleClickDelay is the longest time between the two clicks of a double click
+const doubleClickDelay = 400 * time.Millisecond
+
+// wheelLines is how many lines a notch of the mouse wheel scrolls
+const wheelLines = 3
+
+// uiZone is a part of the screen which runs `action` (or `run`) when clicked, from column `start` to `end` (excluded) of row `y`
+type uiZone struct {
+	y, start, end int
+	action        string
+	run           func(*UI)
+}
+
+// uiMouse remembers the previous mouse events, to find out when a button is pressed and double clicks
+type uiMouse struct {
+	buttons   tcell.ButtonMask
+	clickTime time.Time
+	clickX    int
+	clickY    int
+}
+
+func (ui *UI) addZone(y, start, end int, run func(*UI)) {
+	ui.zones = append(ui.zones, uiZone{y: y, start: start, end: end, run: run})
+}
+
+// addFooterZones makes the key hints of `footer` (e.g. `q:Quit`) on row `y` run their action when clicked
+func (ui *UI) addFooterZones(footer string, y int) {
+	for _, action := range KeyActions {
+		key := ui.keymap.Key(action)
+		if key == "" {
+			continue
+		}
+		hint := fmt.Sprintf("%s:%s", key, actionHelp[action])
+		i := strings.Index(" "+footer+" ", " "+hint+" ")
+		if i < 0 {
+			continue
+		}
+		start := core.StringWidth(footer[:i])
+		ui.zones = append(ui.zones, uiZone{y: y, start: start, end: start + core.StringWidth(hint), action: action})
+	}
+}
+
+// handleMouse scrolls with the wheel (the diagnostics when it is over them) and handles the clicks (when the left button is pressed) on zones, links and the scrollbar
+func (ui *UI) handleMouse(event *tcell.EventMouse) {
+	buttons := event.Buttons()
+	pressed := buttons & ^ui.mouse.buttons
+	ui.mouse.buttons = buttons & (tcell.Button1 | tcell.Button2 | tcell.Button3)
+	if ui.prompt.enabled || ui.form.enabled || ui.hints.enabled || ui.help.enabled {
+		return
+	}
+
+	switch {
+	case buttons&(tcell.WheelUp|tcell.WheelDown) != 0:
+		diff := wheelLines
+		if buttons&tcell.WheelUp != 0 {
+			diff = -wheelLines
+		}
+		if _, y := event.Position(); y <= ui.pageHeight() || !ui.scrollDiagnostics(diff) {
+			ui.scroll(diff, diff > 0)
+		}
+	case pressed&tcell.Button1 != 0:
+		x, y := event.Position()
+		double := time.Since(ui.mouse.clickTime) < doubleClickDelay && x == ui.mouse.clickX && y == ui.mouse.clickY
+		ui.mouse.clickTime, ui.mouse.clickX, ui.mouse.clickY = time.Now(), x, y
+		if double {
+			// A third click starts a new double click
+			ui.mouse.clickTime = time.Time{}
+		}
+		ui.click(x, y, double)
+	}
+}
+
+func (ui *UI) click(x, y int, double bool) {
+	for _, zone := range ui.zones {
+		if zone.y != y || x < zone.start || x >= zone.end {
+			continue
+		}
+		if zone.run != nil {
+			zone.run(ui)
+		} else if ui.isEnabled(zone.action) {
+			ui.actions[zone.action].run(ui)
+		}
+		return
+	}
+
+	w, _ := ui.screen.Size()
+	page := ui.pageHeight()
+	if y < 1 || y > page {
+		return
+	}
+	length := ui.getContentLength()
+	if x == w-1 && length > page {
+		ui.scrollTo((y - 1) * length / page)
+		return
+	}
+
+	follow := double || ui.config.Mouse.SingleClick
+	line := ui.pageOffset() + y - 1
+	if ui.content.kind == NetworkEventOK && line < len(ui.content.lines) && ui.content.lines[line].IsLink() {
+		ui.jumpToLink(uiHint{line: line, link: -1}, follow)
+	} else if link := ui.htmlLinkAt(x, line); link >= 0 {
+		ui.jumpToLink(uiHint{line: line, link: link}, follow)
+	}
+}
+
+// scrollTo scrolls the page so that it starts at `line`
+func (ui *UI) scrollTo(line int) {
+	diff := line - ui.pageOffset()
+	ui.scroll(diff, diff > 0)
+}
+
+// htmlLinkAt returns the index of the HTML link shown at column `x` of `line` (its text or its marker), -1 if there is none
+func (ui *UI) htmlLinkAt(x, line int) int {
+	if ui.content.kind != NetworkEventHTML {
+		return -1
+	}
+	for i, link := range ui.content.html.Links {
+		if link.Line != line {
+			continue
+		}
+		end := core.StringWidth(ui.content.html.Lines[line][:link.Column]) + len(fmt.Sprintf("[%d]", i+1))
+		start := end - len(fmt.Sprintf("[%d]", i+1)) - core.StringWidth(link.Text)
+		if start <= x && x < end {
+			return i
+		}
+	}
+	return -1
+}
@@ -2,105 +2,174 @@ package taupe
 
 import (
 	"fmt"
-	"net/url"
-	"strconv"
 	"strings"
 
 	"github.com/LouisBrunner/taupe/core"
 )
 
 func (ui *UI) doRequest(address string) {
+	ui.load(address, CacheDefault)
+}
+
+// load opens `address`, using the cache according to `policy`
+func (ui *UI) load(address string, policy CachePolicy) {
+	ui.title = ""
+	if isBookmarksAddress(address) {
+		ui.showBookmarks(address)
+		return
+	}
+	if isHistoryAddress(address) {
+		ui.showHistory(address)
+		return
+	}
+	ui.startRequest(func() uint64 {
+		return ui.network.Request(address, policy)
+	})
+}
+
+func (ui *UI) doPost(address string, data []string) {
+	ui.startRequest(func() uint64 {
+		return ui.network.Post(address, data)
+	})
+}
+
+func (ui *UI) startRequest(send func() uint64) {
+	if ui.loading {
+		ui.network.Cancel(ui.request)
+	}
+	// A menu which failed part way is done, the next page must not be appended to it
+	ui.content.streaming = false
+	ui.plus = nil
 	ui.loading = true
-	ui.request = ui.network.Request(address)
+	ui.request = send()
 	ui.render()
 }
 
 func (ui *UI) parseNetworkEvent(event *NetworkEvent) {
+	if _, ok := ui.downloads[event.ID]; ok {
+		ui.parseDownloadEvent(event)
+		return
+	}
+	if tab := ui.tabFor(event.ID); tab != nil {
+		ui.inTab(tab, func() {
+			ui.parseTabEvent(event)
+		})
+	}
+}
+
+func (ui *UI) parseTabEvent(event *NetworkEvent) {
+	if event.Event == NetworkEventChunk {
+		ui.parseMenu(event.Result, false)
+		return
+	}
 	ui.loading = false
+	if event.Event != NetworkEventError {
+		ui.secure = event.Secure
+		ui.cached = event.Cached
+	}
 	switch event.Event {
 	case NetworkEventOK:
-		result := event.Result
-		ui.parseNetworkCommon(event.Event, result.Address)
-		ui.content.lines = ui.parseLines(result.List)
-		ui.selectLink(1)
+		ui.parseMenu(event.Result, true)
 	case NetworkEventHTML:
 		result := event.ResultHTML
 		ui.parseNetworkCommon(event.Event, result.Address)
-		ui.content.html = ui.parseHTML(result.HTML)
-		ui.render()
+		ui.content.source = result.HTML
+		ui.content.link = -1
+		ui.renderHTML()
+		ui.scrollText(1)
+	case NetworkEventText:
+		result := event.ResultText
+		ui.parseNetworkCommon(event.Event, result.Address)
+		ui.content.text = result.Lines
+		ui.wrapText()
+		ui.scrollText(1)
+	case NetworkEventAttributes:
+		ui.parseAttributes(event.ResultAttributes)
 	case NetworkEventError:
-		ui.setStatus(fmt.Sprintf("Network error: %v", event.ResultError))
+		ui.plus = nil
+		ui.content.streaming = false
+		tab := ui.uiTab
+		ui.setNetworkError(event.ResultError, func() {
+			if ui.selectTab(tab) {
+				ui.doRequest(event.ResultError.(*NetworkCertificateError).Address)
+			}
+		})
 	}
 	ui.history.wasPrevious = false
+	ui.history.line = 0
 }
 
-func (ui *UI) parseNetworkCommon(event NetworkEventType, address string) {
-	ui.content.kind = event
-	history := ui.address
-	oldURL, err := url.Parse(ui.address)
-	if err == nil {
-		q := oldURL.Query()
-		q.Set("l", strconv.Itoa(ui.content.line))
-		oldURL.RawQuery = q.Encode()
-		history = oldURL.String()
-	}
-	if ui.history.wasPrevious {
-		ui.history.after = append([]string{history}, ui.history.after...)
+// setNetworkError reports `err` to the user, `retry` is called if they decide to trust a changed certificate
+func (ui *UI) setNetworkError(err error, retry func()) {
+	if certErr, changed := err.(*NetworkCertificateError); changed {
+		ui.confirmCertificate(certErr, retry)
+	} else if _, timeout := err.(*NetworkTimeoutError); timeout {
+		ui.setStatus(fmt.Sprintf("Timeout: %v", err))
+	} else if err == ErrNetworkOffline {
+		ui.setStatus("Offline: page not cached")
+	} else if err == ErrNetworkCanceled {
+		ui.setStatus("Request canceled")
 	} else {
-		ui.history.before = append([]string{history}, ui.history.before...)
-	}
-	ui.address = address
-	newURL, err := url.Parse(ui.address)
-	ui.content.line = -1
-	if err == nil {
-		if val, ok := newURL.Query()["l"]; ok {
-			line, err := strconv.Atoi(val[0])
-			if err == nil {
-				ui.content.line = line - 1
-			}
-		}
+		ui.setStatus(fmt.Sprintf("Network error: %v", err))
 	}
 }
 
-func (ui *UI) parseHTML(html string) []string {
-	lines := strings.Split(html, "\n")
-	result := []string{}
-
-	w, _ := ui.screen.Size()
-	pivot := w - 2
+func (ui *UI) confirmCertificate(err *NetworkCertificateError, retry func()) {
+	ui.setStatus(fmt.Sprintf("WARNING: %v, someone could be intercepting the connection", err))
+	label := fmt.Sprintf("Certificate of %s changed, trust the new one? [y/N]", err.Host)
+	ui.openPrompt(label, "", nil, func(answer string) {
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+			ui.setStatus(fmt.Sprintf("Kept the known certificate of %s", err.Host))
+			return
+		}
+		if trustErr := ui.network.Trust(err.Host, err.Fingerprint); trustErr != nil {
+			ui.setStatus(fmt.Sprintf("Error: cannot save the certificate: %v", trustErr))
+			return
+		}
+		retry()
+	})
+}
 
-	for _, line := range lines {
-		if len(line) > w {
-			result = append(result, line[:w])
-			rest := line[w:]
-			for {
-				irest := rest
-				if len(rest) > w {
-					irest = rest[:pivot]
-				}
-				result = append(result, fmt.Sprintf("| %s", irest))
-				if len(rest) > w {
-					rest = rest[pivot:]
-				} else {
-					break
-				}
-			}
+func (ui *UI) parseNetworkCommon(event NetworkEventType, address string) {
+	ui.content.kind = event
+	ui.content.streaming = false
+	ui.content.find = uiFind{}
+	if !ui.hidden {
+		ui.hints = uiHints{}
+	}
+	history := uiHistoryEntry{address: ui.address, line: ui.content.line}
+	// Reloading the same page (e.g. refreshing it) doesn't change the history
+	if address != ui.address {
+		if ui.history.wasPrevious {
+			ui.history.after = append([]uiHistoryEntry{history}, ui.history.after...)
 		} else {
-			result = append(result, line)
+			ui.history.before = append([]uiHistoryEntry{history}, ui.history.before...)
 		}
 	}
-	return result
+	ui.address = address
+	ui.content.line = ui.history.line - 1
+	ui.content.offset = 0
+	ui.logVisit()
 }
 
-func (ui *UI) parseLines(lines []string) []*core.Record {
-	result := []*core.Record{}
-	for _, line := range lines {
-		record, err := core.ParseRecord(line)
-		if err != nil {
-			ui.setStatus(fmt.Sprintf("Error: while parsing `%s`", line))
-			return []*core.Record{}
-		}
-		result = append(result, record)
+func (ui *UI) parseMenu(result *NetworkResult, done bool) {
+	if !ui.content.streaming {
+		ui.parseNetworkCommon(NetworkEventOK, result.Address)
+		ui.content.lines = []*core.Record{}
+		ui.content.warnings = []core.MenuWarning{}
+		ui.content.diagnostics = false
+		ui.content.diagnosticsOffset = 0
+	}
+	ui.content.streaming = !done
+	ui.content.lines = append(ui.content.lines, result.Records...)
+	ui.content.warnings = append(ui.content.warnings, result.Warnings...)
+	if done && len(ui.content.lines) == 0 && len(ui.content.warnings) > 0 {
+		ui.content.diagnostics = true
+	}
+	// The line to restore may only come in a later chunk, it is selected once it is there
+	if ui.content.line == ui.history.line-1 || !ui.hasSelection() {
+		ui.selectLink(1)
+	} else {
+		ui.render()
 	}
-	return result
 }
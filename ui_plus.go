@@ -0,0 +1,150 @@
+package taupe
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/LouisBrunner/taupe/core"
+)
+
+// fetchAttributes requests the Gopher+ attributes of `record` and gives them to `then` instead of displaying them
+func (ui *UI) fetchAttributes(record *core.Record, then func(*core.Attributes)) {
+	url, err := core.ParseURL(record.Address)
+	if err != nil {
+		ui.setStatus(fmt.Sprintf("Error: %v", err))
+		return
+	}
+	url.Plus = core.PlusAttributes
+	ui.doRequest(url.String())
+	ui.plus = then
+}
+
+func (ui *UI) parseAttributes(result *NetworkResultAttributes) {
+	if ui.plus != nil {
+		then := ui.plus
+		ui.plus = nil
+		if len(result.Items) < 1 {
+			ui.setStatus("Error: the server didn't send any attributes")
+			return
+		}
+		then(result.Items[0])
+		return
+	}
+	ui.parseNetworkCommon(NetworkEventText, result.Address)
+	ui.content.text = formatAttributes(result.Items)
+	ui.wrapText()
+	ui.scrollText(1)
+}
+
+func (ui *UI) selectedPlus() *core.Record {
+	if ui.content.kind != NetworkEventOK || ui.content.line < 0 || ui.content.line >= len(ui.content.lines) {
+		return nil
+	}
+	if line := ui.content.lines[ui.content.line]; line.Plus {
+		return line
+	}
+	return nil
+}
+
+func (ui *UI) inspect() {
+	if record := ui.selectedPlus(); record != nil {
+		url, err := core.ParseURL(record.Address)
+		if err != nil {
+			ui.setStatus(fmt.Sprintf("Error: %v", err))
+			return
+		}
+		url.Plus = core.PlusAttributes
+		ui.doRequest(url.String())
+	} else if ui.content.kind == NetworkEventOK {
+		url, err := core.ParseURL(ui.address)
+		if err != nil {
+			ui.setStatus(fmt.Sprintf("Error: %v", err))
+			return
+		}
+		url.Plus = core.PlusDirectoryAttributes
+		ui.doRequest(url.String())
+	} else {
+		ui.setStatus("Error: no Gopher+ attributes to show")
+	}
+}
+
+func (ui *UI) selectView() {
+	record := ui.selectedPlus()
+	if record == nil {
+		ui.setStatus("Error: not a Gopher+ item")
+		return
+	}
+	ui.fetchAttributes(record, func(attributes *core.Attributes) {
+		views := attributes.Views()
+		if len(views) < 1 {
+			ui.setStatus(fmt.Sprintf("Error: %s has no views", record.Display))
+			return
+		}
+		choices := make([]string, len(views))
+		for i, view := range views {
+			choices[i] = fmt.Sprintf("%d) %s", i+1, view)
+		}
+		label := fmt.Sprintf("View [%s]", strings.Join(choices, ", "))
+		ui.openPrompt(label, "1", nil, func(input string) {
+			choice, err := strconv.Atoi(strings.TrimSpace(input))
+			if err != nil || choice < 1 || choice > len(views) {
+				ui.setStatus(fmt.Sprintf("Error: invalid view `%s`", input))
+				return
+			}
+			ui.openView(record, views[choice-1])
+		})
+	})
+}
+
+func (ui *UI) openView(record *core.Record, view core.View) {
+	url, err := core.ParseURL(record.Address)
+	if err != nil {
+		ui.setStatus(fmt.Sprintf("Error: %v", err))
+		return
+	}
+	url.Type = view.Entry()
+	url.Plus = view.Plus()
+
+	viewed := *record
+	viewed.Type = url.Type
+	viewed.Address = url.String()
+	if viewed.IsBinary() {
+		ui.saveAs(&viewed)
+	} else {
+		ui.doRequest(viewed.Address)
+	}
+}
+
+func (ui *UI) ask(record *core.Record) {
+	ui.fetchAttributes(record, func(attributes *core.Attributes) {
+		fields := attributes.Ask()
+		if len(fields) < 1 {
+			ui.setStatus(fmt.Sprintf("Error: %s has no form", record.Display))
+			return
+		}
+		ui.openForm(record, fields)
+	})
+}
+
+func formatAttributes(items []*core.Attributes) []string {
+	lines := []string{}
+	for i, item := range items {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		if item.Info != nil {
+			lines = append(lines, item.Info.ToString(), item.Info.Address)
+		}
+		for _, block := range item.Blocks {
+			if block.Name == "INFO" {
+				continue
+			}
+			lines = append(lines, strings.TrimSpace(fmt.Sprintf("+%s: %s", block.Name, block.Value)))
+			for _, line := range block.Lines {
+				lines = append(lines, "  "+line)
+			}
+		}
+	}
+	return lines
+}
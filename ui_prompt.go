@@ -0,0 +1,187 @@
+package taupe
+
+import (
+	"unicode"
+
+	"github.com/LouisBrunner/taupe/core"
+
+	"github.com/gdamore/tcell"
+)
+
+type uiPrompt struct {
+	enabled  bool
+	label    string
+	text     []rune
+	cursor   int
+	killed   []rune
+	history  *[]string
+	recall   int
+	draft    []rune
+	onSubmit func(string)
+	// onChange is called when the text is edited, onCancel when the prompt is closed without submitting it
+	// and onKey with the keys the prompt doesn't use itself, returning if it used them
+	onChange func(string)
+	onCancel func()
+	onKey    func(*tcell.EventKey) bool
+	// hint is shown at the right of the prompt
+	hint string
+}
+
+func (ui *UI) openPrompt(label, initial string, history *[]string, onSubmit func(string)) {
+	text := []rune(initial)
+	ui.prompt = uiPrompt{
+		enabled:  true,
+		label:    label,
+		text:     text,
+		cursor:   len(text),
+		killed:   ui.prompt.killed,
+		history:  history,
+		recall:   -1,
+		onSubmit: onSubmit,
+	}
+	ui.render()
+}
+
+func (ui *UI) closePrompt() {
+	ui.prompt.enabled = false
+	ui.render()
+}
+
+func (ui *UI) handlePromptKey(event *tcell.EventKey) {
+	prompt := &ui.prompt
+	before := string(prompt.text)
+	switch event.Key() {
+	case tcell.KeyRune:
+		prompt.insert([]rune{event.Rune()})
+	case tcell.KeyEnter:
+		text := string(prompt.text)
+		onSubmit := prompt.onSubmit
+		if prompt.history != nil && text != "" {
+			*prompt.history = append([]string{text}, *prompt.history...)
+		}
+		ui.closePrompt()
+		onSubmit(text)
+		return
+	case tcell.KeyEscape, tcell.KeyCtrlC, tcell.KeyCtrlG:
+		onCancel := prompt.onCancel
+		ui.closePrompt()
+		if onCancel != nil {
+			onCancel()
+		}
+		return
+	case tcell.KeyLeft, tcell.KeyCtrlB:
+		prompt.cursor = imax(prompt.cursor-1, 0)
+	case tcell.KeyRight, tcell.KeyCtrlF:
+		prompt.cursor = imin(prompt.cursor+1, len(prompt.text))
+	case tcell.KeyHome, tcell.KeyCtrlA:
+		prompt.cursor = 0
+	case tcell.KeyEnd, tcell.KeyCtrlE:
+		prompt.cursor = len(prompt.text)
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		prompt.remove(imax(prompt.cursor-1, 0), prompt.cursor)
+	case tcell.KeyDelete, tcell.KeyCtrlD:
+		prompt.remove(prompt.cursor, imin(prompt.cursor+1, len(prompt.text)))
+	case tcell.KeyCtrlW:
+		prompt.kill(prompt.previousWord(), prompt.cursor)
+	case tcell.KeyCtrlU:
+		prompt.kill(0, prompt.cursor)
+	case tcell.KeyCtrlK:
+		prompt.kill(prompt.cursor, len(prompt.text))
+	case tcell.KeyCtrlY:
+		prompt.insert(prompt.killed)
+	case tcell.KeyUp:
+		prompt.recallHistory(1)
+	case tcell.KeyDown:
+		prompt.recallHistory(-1)
+	default:
+		if prompt.onKey != nil && prompt.onKey(event) {
+			return
+		}
+	}
+	if text := string(prompt.text); text != before && prompt.onChange != nil {
+		prompt.onChange(text)
+	}
+	ui.render()
+}
+
+func (prompt *uiPrompt) insert(runes []rune) {
+	text := make([]rune, 0, len(prompt.text)+len(runes))
+	text = append(text, prompt.text[:prompt.cursor]...)
+	text = append(text, runes...)
+	text = append(text, prompt.text[prompt.cursor:]...)
+	prompt.text = text
+	prompt.cursor += len(runes)
+}
+
+func (prompt *uiPrompt) remove(from, to int) {
+	if from >= to {
+		return
+	}
+	prompt.text = append(prompt.text[:from:from], prompt.text[to:]...)
+	prompt.cursor = from
+}
+
+func (prompt *uiPrompt) kill(from, to int) {
+	if from >= to {
+		return
+	}
+	prompt.killed = append([]rune{}, prompt.text[from:to]...)
+	prompt.remove(from, to)
+}
+
+func (prompt *uiPrompt) previousWord() int {
+	i := prompt.cursor
+	for i > 0 && unicode.IsSpace(prompt.text[i-1]) {
+		i--
+	}
+	for i > 0 && !unicode.IsSpace(prompt.text[i-1]) && prompt.text[i-1] != '/' {
+		i--
+	}
+	if i == prompt.cursor && i > 0 {
+		i--
+	}
+	return i
+}
+
+func (prompt *uiPrompt) recallHistory(diff int) {
+	if prompt.history == nil {
+		return
+	}
+	history := *prompt.history
+	recall := prompt.recall + diff
+	if recall < -1 || recall >= len(history) {
+		return
+	}
+	if prompt.recall == -1 {
+		prompt.draft = prompt.text
+	}
+	prompt.recall = recall
+	if recall == -1 {
+		prompt.text = prompt.draft
+	} else {
+		prompt.text = []rune(history[recall])
+	}
+	prompt.cursor = len(prompt.text)
+}
+
+func (ui *UI) renderPrompt(y int) {
+	w, _ := ui.screen.Size()
+	prompt := ui.prompt
+
+	label := prompt.label + ": "
+	hint := 0
+	if prompt.hint != "" {
+		hint = core.StringWidth(prompt.hint) + 2
+	}
+	room := imax(w-core.StringWidth(label)-hint-1, 1)
+	start := prompt.cursor
+	for start > 0 && core.StringWidth(string(prompt.text[start-1:prompt.cursor])) <= room {
+		start--
+	}
+
+	ui.renderLine(0, y, ljust(label+string(prompt.text[start:]), w), ui.theme.Style("prompt"))
+	if hint > 0 {
+		ui.renderLine(imax(w-hint+1, 0), y, prompt.hint, ui.theme.Style("prompt"))
+	}
+	ui.screen.ShowCursor(core.StringWidth(label+string(prompt.text[start:prompt.cursor])), y)
+}
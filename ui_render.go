@@ -2,69 +2,191 @@ package taupe
 
 import (
 	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/LouisBrunner/taupe/core"
 
 	"github.com/gdamore/tcell"
 )
 
 func (ui *UI) render() {
+	if ui.hidden {
+		return
+	}
+	st := ui.theme.Style("text")
+	ui.screen.SetStyle(st)
 	ui.screen.Clear()
+	ui.scrollToSelection()
+	ui.zones = nil
 
 	w, h := ui.screen.Size()
-	middle := h / 2
-
-	st := tcell.StyleDefault
+	page := ui.pageHeight()
 
-	header := fmt.Sprintf("Taupe: %s", ui.address)
-	ui.renderLine(0, 0, ljust(header, w), st.Reverse(true))
+	ui.renderHeader()
 
 	length := ui.getContentLength()
-	offset := 0
-	if ui.content.line > middle {
-		offset = imin(ui.content.line-middle, length-h+2)
-	}
-	if ui.content.kind == NetworkEventOK {
-		for i := offset; i-offset < h-2 && i < length; i++ {
-			line := ui.content.lines[i]
-			style := st
-			if line.IsLink() {
-				style = style.Underline(true)
-			}
+	offset := ui.pageOffset()
+	if ui.help.enabled {
+		ui.renderHelp(page)
+	} else if ui.form.enabled {
+		ui.renderForm(page, st)
+	} else if ui.content.kind == NetworkEventOK {
+		lines := ui.menuLines()
+		for i := offset; i-offset < page && i < length; i++ {
+			style := ui.theme.RecordStyle(ui.content.lines[i])
 			if i == ui.content.line {
-				style = style.Bold(true)
+				style = ui.theme.Style("selection")
 			}
-			ui.renderLine(0, i-offset+1, line.ToString(), style)
+			ui.renderLine(0, i-offset+1, lines[i], style)
 		}
 	} else if ui.content.kind == NetworkEventHTML {
-		for i := offset; i-offset < h-2 && i < length; i++ {
-			line := ui.content.html[i]
-			style := st
-			if i == ui.content.line {
-				style = style.Bold(true)
-			}
-			ui.renderLine(0, i-offset+1, line, style)
+		for i := offset; i-offset < page && i < length; i++ {
+			ui.renderLine(0, i-offset+1, ui.content.html.Lines[i], st)
 		}
+		ui.renderHTMLLinks(offset, page)
+	} else if ui.content.kind == NetworkEventText {
+		for i := offset; i-offset < page && i < length; i++ {
+			ui.renderLine(0, i-offset+1, ui.content.wrapped[i], st)
+		}
+	}
+	if !ui.form.enabled && !ui.help.enabled {
+		ui.renderMatches(offset, page)
+		ui.renderHints(offset)
+		ui.renderScrollbar(offset, page)
 	}
+	ui.renderDiagnostics(page + 1)
 
 	var status string
-	if ui.status.enabled {
+	if len(ui.keys) > 0 {
+		status = formatKeys(ui.keys) + "-"
+	} else if ui.status.enabled {
 		status = ui.status.message
+	} else if ui.loading && ui.content.streaming {
+		status = fmt.Sprintf("Still receiving (%d items so far, %s to cancel)", len(ui.content.lines), ui.keymap.Key("cancel"))
 	} else if ui.loading {
-		status = "Loading..."
+		status = fmt.Sprintf("Loading... (%s to cancel)", ui.keymap.Key("cancel"))
+	} else if len(ui.downloads) > 0 {
+		status = fmt.Sprintf("%s (%s to cancel)", ui.downloadStatus(), ui.keymap.Key("cancel-downloads"))
 	}
 
-	footer := "[Q]uit/Esc/Ctrl+C [R]efresh Up Down Enter [B]ack/Backspace [F]orward [I]nput"
-	if len(status) > 0 {
-		footer = footer + " | " + status
+	if ui.prompt.enabled {
+		ui.renderPrompt(h - 1)
+	} else {
+		footer := ui.keyHelp("quit", "refresh", "open", "back", "forward", "input", "bookmark", "bookmarks", "history", "help")
+		if len(ui.tabs) > 1 {
+			footer = footer + " " + ui.keyHelp("next-tab", "close-tab")
+		}
+		if ui.help.enabled {
+			footer = "Up/Down/PgUp/PgDn Scroll, any other key closes the help"
+		} else if ui.hints.enabled {
+			footer = "Type the label of a link to follow it (in uppercase to only select it), Esc to cancel"
+		} else if ui.form.enabled {
+			footer = "Tab/Up/Down Move Left/Right/Space Choose Enter Next/Submit Ctrl+S Submit Esc Cancel"
+		} else if ui.onHistory() {
+			footer = footer + " | " + ui.keyHelp("search-history")
+		} else if ui.onBookmarks() {
+			footer = footer + " | " + ui.keyHelp("rename-bookmark", "delete-bookmark", "move-bookmark", "create-folder", "export-bookmarks", "import-bookmarks")
+		} else if ui.selectedPlus() != nil {
+			footer = footer + " | Gopher+ " + ui.keyHelp("attributes", "views")
+		} else if link := ui.selectedHTMLLink(); link != nil {
+			footer = footer + fmt.Sprintf(" | [%d] %s | %s", ui.content.link+1, link.URL, ui.textPosition())
+		} else if target := ui.selectedURL(); target != "" {
+			footer = footer + " | URL: " + target
+		} else if ui.content.kind == NetworkEventOK && len(ui.content.warnings) > 0 {
+			footer = footer + fmt.Sprintf(" | %d warnings %s", len(ui.content.warnings), ui.keyHelp("diagnostics"))
+		} else if ui.getContentLength() > 0 {
+			footer = footer + " | " + ui.textPosition()
+		}
+		if find := ui.findStatus(); find != "" && !ui.form.enabled && !ui.help.enabled {
+			footer = footer + " | " + find
+		}
+		if len(status) > 0 {
+			footer = footer + " | "
+		}
+		ui.renderLine(0, h-1, ljust(footer, w), ui.theme.Style("footer"))
+		if !ui.hints.enabled && !ui.form.enabled && !ui.help.enabled {
+			ui.addFooterZones(footer, h-1)
+		}
+		ui.renderLine(core.StringWidth(footer), h-1, status, ui.theme.Style("status"))
+		if !ui.form.enabled {
+			ui.screen.HideCursor()
+		}
 	}
-	ui.renderLine(0, h-1, ljust(footer, w), st.Reverse(true))
 
 	ui.screen.Sync()
 }
 
+// menuLines returns the lines of the menu as they are shown, prefixed with the number of each link when they are numbered
+func (ui *UI) menuLines() []string {
+	lines := make([]string, len(ui.content.lines))
+	if !ui.linkNumbers {
+		for i, line := range ui.content.lines {
+			lines[i] = line.ToString()
+		}
+		return lines
+	}
+	count := 0
+	for _, line := range ui.content.lines {
+		if line.IsLink() {
+			count++
+		}
+	}
+	width := len(fmt.Sprint(count))
+	number := 0
+	for i, line := range ui.content.lines {
+		prefix := strings.Repeat(" ", width+1)
+		if line.IsLink() {
+			number++
+			prefix = fmt.Sprintf("%*d ", width, number)
+		}
+		lines[i] = prefix + line.ToString()
+	}
+	return lines
+}
+
+// renderHTMLLinks highlights the `[n]` markers of the links on screen, the selected one with the selection style
+func (ui *UI) renderHTMLLinks(offset, page int) {
+	for i, link := range ui.content.html.Links {
+		if link.Line < offset || link.Line-offset >= page {
+			continue
+		}
+		linkStyle := ui.theme.Style("link")
+		if i == ui.content.link {
+			linkStyle = ui.theme.Style("selection")
+		}
+		column := core.StringWidth(ui.content.html.Lines[link.Line][:link.Column])
+		ui.renderLine(column, link.Line-offset+1, fmt.Sprintf("[%d]", i+1), linkStyle)
+	}
+}
+
+// renderLine draws `line` from column `x`, wide characters take two columns and combining ones are drawn over the previous one
 func (ui *UI) renderLine(x, y int, line string, style tcell.Style) {
 	w, h := ui.screen.Size()
+	if y >= h {
+		return
+	}
 
-	for i := x; i < len(line) && i < w && y < h; i++ {
-		ui.screen.SetContent(i, y, rune(line[i]), nil, style)
+	var main rune
+	var combining []rune
+	previous := -1
+	for _, char := range line {
+		if unicode.IsControl(char) {
+			continue
+		}
+		width := core.RuneWidth(char)
+		if width == 0 {
+			if previous >= 0 {
+				combining = append(combining, char)
+				ui.screen.SetContent(previous, y, main, combining, style)
+			}
+			continue
+		}
+		if x+width > w {
+			break
+		}
+		main, combining, previous = char, nil, x
+		ui.screen.SetContent(x, y, char, nil, style)
+		x += width
 	}
 }
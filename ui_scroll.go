@@ -0,0 +1,124 @@
+package taupe
+
+import (
+	"github.com/gdamore/tcell"
+)
+
+// pageOffset returns the first line of the page on screen
+func (ui *UI) pageOffset() int {
+	if ui.content.kind == NetworkEventText || ui.content.kind == NetworkEventHTML {
+		return ui.content.line
+	}
+	return ui.content.offset
+}
+
+func (ui *UI) isLineVisible(line int) bool {
+	offset := ui.pageOffset()
+	return offset <= line && line < offset+ui.pageHeight()
+}
+
+// scrollToSelection scrolls a menu to its selected line when it is off screen (e.g. after a search), putting it in the middle
+func (ui *UI) scrollToSelection() {
+	if ui.content.kind != NetworkEventOK {
+		return
+	}
+	page := ui.pageHeight()
+	last := imax(len(ui.content.lines)-page, 0)
+	line := ui.content.line
+	if line >= 0 && line < len(ui.content.lines) && !ui.isLineVisible(line) {
+		ui.content.offset = line - page/2
+	}
+	ui.content.offset = imax(imin(ui.content.offset, last), 0)
+}
+
+// scroll moves the page by `diff` lines, the selection stays where it is unless it goes off screen:
+// the first link on screen (or the last one if `first` isn't set) is then selected instead
+func (ui *UI) scroll(diff int, first bool) {
+	switch ui.content.kind {
+	case NetworkEventText:
+		ui.scrollText(diff)
+	case NetworkEventHTML:
+		ui.scrollText(diff)
+		if link := ui.selectedHTMLLink(); link != nil && !ui.isLinkVisible(link) {
+			ui.content.link = -1
+			ui.render()
+		}
+	case NetworkEventOK:
+		last := imax(len(ui.content.lines)-ui.pageHeight(), 0)
+		ui.content.offset = imax(imin(ui.content.offset+diff, last), 0)
+		if !ui.isLineVisible(ui.content.line) {
+			ui.content.line = ui.visibleLink(first)
+		}
+		ui.render()
+	}
+}
+
+// scrollLines scrolls the diagnostics by `diff` lines while they are shown, the page otherwise
+func (ui *UI) scrollLines(diff int) {
+	if !ui.scrollDiagnostics(diff) {
+		ui.scroll(diff, diff > 0)
+	}
+}
+
+// visibleLink returns the first (or last) link of the menu on screen, -1 if there is none
+func (ui *UI) visibleLink(first bool) int {
+	offset := ui.pageOffset()
+	end := imin(offset+ui.pageHeight(), len(ui.content.lines))
+	for i := offset; i < end; i++ {
+		line := i
+		if !first {
+			line = end - 1 - (i - offset)
+		}
+		if ui.content.lines[line].IsLink() {
+			return line
+		}
+	}
+	return -1
+}
+
+// moveMenu selects the next (or previous) link of a menu, scrolling line by line until it is on screen
+func (ui *UI) moveMenu(diff int) {
+	from := ui.content.line
+	if !ui.isLineVisible(from) {
+		from = ui.pageOffset() - 1
+		if diff < 0 {
+			from = ui.pageOffset() + ui.pageHeight()
+		}
+	}
+	next := -1
+	for i := from + diff; 0 <= i && i < len(ui.content.lines); i += diff {
+		if ui.content.lines[i].IsLink() {
+			next = i
+			break
+		}
+	}
+	if next < 0 || !ui.isLineVisible(next) {
+		ui.scroll(diff, diff > 0)
+	}
+	if next >= 0 && ui.isLineVisible(next) {
+		ui.content.line = next
+	}
+	ui.render()
+}
+
+// renderScrollbar draws a scrollbar in the last column when the page doesn't fit on screen
+func (ui *UI) renderScrollbar(offset, page int) {
+	length := ui.getContentLength()
+	if length <= page {
+		return
+	}
+	w, _ := ui.screen.Size()
+	size := imax(page*page/length, 1)
+	start := imin(offset*page/length, page-size)
+	if offset+page >= length {
+		start = page - size
+	}
+	style := ui.theme.Style("scrollbar")
+	for y := 0; y < page; y++ {
+		char := tcell.RuneVLine
+		if y >= start && y < start+size {
+			char = tcell.RuneBlock
+		}
+		ui.screen.SetContent(w-1, y+1, char, nil, style)
+	}
+}
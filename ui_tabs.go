@@ -0,0 +1,147 @@
+package taupe
+
+import (
+	"fmt"
+
+	"github.com/LouisBrunner/taupe/core"
+)
+
+// tabNameWidth is the longest a tab name can be in the tab strip
+const tabNameWidth = 20
+
+// tabFor returns the tab waiting for the request `id`, if any
+func (ui *UI) tabFor(id uint64) *uiTab {
+	for _, tab := range ui.tabs {
+		if tab.loading && tab.request == id {
+			return tab
+		}
+	}
+	return nil
+}
+
+// inTab runs `action` as if `tab` was visible, without drawing anything if it isn't
+func (ui *UI) inTab(tab *uiTab, action func()) {
+	visible := ui.uiTab
+	if tab == visible {
+		action()
+		return
+	}
+	ui.uiTab, ui.hidden = tab, true
+	action()
+	ui.uiTab, ui.hidden = visible, false
+	ui.render()
+}
+
+func (ui *UI) tabIndex(tab *uiTab) int {
+	for i, other := range ui.tabs {
+		if other == tab {
+			return i
+		}
+	}
+	return -1
+}
+
+// selectTab makes `tab` visible, it returns false if the tab was closed
+func (ui *UI) selectTab(tab *uiTab) bool {
+	if ui.tabIndex(tab) < 0 {
+		return false
+	}
+	ui.uiTab = tab
+	ui.resize()
+	return true
+}
+
+// newTab opens `address` in a new tab, right after the visible one
+func (ui *UI) newTab(address string) {
+	tab := &uiTab{address: address}
+	i := ui.tabIndex(ui.uiTab) + 1
+	ui.tabs = append(ui.tabs[:i], append([]*uiTab{tab}, ui.tabs[i:]...)...)
+	ui.uiTab = tab
+	ui.doRequest(address)
+}
+
+func (ui *UI) openInTab() {
+	if ui.content.kind != NetworkEventOK || ui.content.line < 0 || ui.content.line >= len(ui.content.lines) {
+		ui.setStatus("Error: nothing selectable")
+		return
+	}
+	line := ui.content.lines[ui.content.line]
+	if !line.IsLink() || line.IsBinary() || line.Ask || line.Type == core.TypeSearch || line.URL != "" {
+		ui.setStatus("Error: cannot open this item in a new tab")
+		return
+	}
+	title := line.Display
+	if ui.onHistory() {
+		title = ""
+	}
+	ui.newTab(line.Address)
+	if !isLocalAddress(line.Address) {
+		ui.title = title
+	}
+}
+
+func (ui *UI) closeTab() {
+	if len(ui.tabs) < 2 {
+		ui.setStatus("Error: cannot close the last tab")
+		return
+	}
+	if ui.loading {
+		ui.network.Cancel(ui.request)
+	}
+	i := ui.tabIndex(ui.uiTab)
+	ui.tabs = append(ui.tabs[:i], ui.tabs[i+1:]...)
+	ui.selectTab(ui.tabs[imin(i, len(ui.tabs)-1)])
+}
+
+func (ui *UI) cycleTab(diff int) {
+	count := len(ui.tabs)
+	i := ui.tabIndex(ui.uiTab)
+	ui.selectTab(ui.tabs[((i+diff)%count+count)%count])
+}
+
+func (tab *uiTab) label() string {
+	name := tab.name
+	if name == "" {
+		name = tab.address
+	}
+	if core.StringWidth(name) > tabNameWidth {
+		name = truncate(name, tabNameWidth-3) + "..."
+	}
+	if tab.loading {
+		name = "*" + name
+	}
+	return name
+}
+
+// renderHeader draws the tab strip followed by the address of the visible tab
+func (ui *UI) renderHeader() {
+	w, _ := ui.screen.Size()
+	address := ui.address
+	if ui.secure {
+		address = "[TLS] " + address
+	}
+	if ui.cached {
+		address = "[cached] " + address
+	}
+	if ui.config.Cache.Offline {
+		address = "[offline] " + address
+	}
+	if len(ui.tabs) < 2 {
+		ui.renderLine(0, 0, ljust(fmt.Sprintf("Taupe: %s", address), w), ui.theme.Style("header"))
+		return
+	}
+
+	x := 0
+	for i, tab := range ui.tabs {
+		label := fmt.Sprintf(" %d:%s ", i+1, tab.label())
+		tabStyle := ui.theme.Style("tab")
+		if tab == ui.uiTab {
+			tabStyle = ui.theme.Style("tab-active")
+		}
+		ui.renderLine(x, 0, label, tabStyle)
+		tab := tab
+		ui.addZone(0, x, x+core.StringWidth(label), func(ui *UI) { ui.selectTab(tab) })
+		x += core.StringWidth(label)
+	}
+	ui.renderLine(x, 0, ljust(" "+address, imax(w-x, 0)), ui.theme.Style("header"))
+}
@@ -0,0 +1,47 @@
+package taupe
+
+import (
+	"fmt"
+)
+
+// wrapText wraps the text to the width of the screen, less the last column when the scrollbar is shown
+func (ui *UI) wrapText() {
+	w, _ := ui.screen.Size()
+	ui.content.wrapped = ui.wrapLines(w)
+	if ui.wrap && len(ui.content.wrapped) > ui.pageHeight() {
+		ui.content.wrapped = ui.wrapLines(w - 1)
+	}
+}
+
+func (ui *UI) wrapLines(width int) []string {
+	wrapped := []string{}
+	for _, line := range ui.content.text {
+		if !ui.wrap {
+			wrapped = append(wrapped, expandTabs(line))
+			continue
+		}
+		wrapped = append(wrapped, wrapText(expandTabs(line), width)...)
+	}
+	return wrapped
+}
+
+func (ui *UI) pageHeight() int {
+	_, h := ui.screen.Size()
+	return imax(h-2-ui.diagnosticsHeight(), 1)
+}
+
+func (ui *UI) scrollText(diff int) {
+	last := imax(ui.getContentLength()-ui.pageHeight(), 0)
+	ui.content.line = imax(imin(ui.content.line+diff, last), 0)
+	ui.render()
+}
+
+func (ui *UI) textPosition() string {
+	length := ui.getContentLength()
+	if length == 0 {
+		return "Empty"
+	}
+	first := ui.pageOffset() + 1
+	last := imin(first-1+ui.pageHeight(), length)
+	return fmt.Sprintf("Lines %d-%d/%d %d%%", first, last, length, last*100/length)
+}
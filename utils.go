@@ -1,9 +1,18 @@
 package taupe
 
 import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"path"
 	"strings"
+	"unicode/utf8"
+
+	"github.com/LouisBrunner/taupe/core"
 )
 
+const tabWidth = 8
+
 func imin(a, b int) int {
 	if a < b {
 		return a
@@ -18,10 +27,215 @@ func imax(a, b int) int {
 	return b
 }
 
+// ljust cuts or pads `s` to take exactly `total` columns
 func ljust(s string, total int) string {
-	length := len(s)
-	if total < length {
-		return s[:total]
+	s = truncate(s, total)
+	return s + strings.Repeat(" ", total-core.StringWidth(s))
+}
+
+// truncate cuts `s` to take at most `total` columns
+func truncate(s string, total int) string {
+	width := 0
+	for i, char := range s {
+		width += core.RuneWidth(char)
+		if width > total {
+			return s[:i]
+		}
+	}
+	return s
+}
+
+func expandTabs(s string) string {
+	if !strings.Contains(s, "\t") {
+		return s
+	}
+	var result bytes.Buffer
+	column := 0
+	for _, char := range s {
+		if char == '\t' {
+			spaces := tabWidth - column%tabWidth
+			result.WriteString(strings.Repeat(" ", spaces))
+			column += spaces
+		} else {
+			result.WriteRune(char)
+			column += core.RuneWidth(char)
+		}
+	}
+	return result.String()
+}
+
+// wrapText splits `s` in lines of at most `width` columns, breaking at the last space when there is one
+func wrapText(s string, width int) []string {
+	if width < 1 || core.StringWidth(s) <= width {
+		return []string{s}
+	}
+	result := []string{}
+	for core.StringWidth(s) > width {
+		cut := len(truncate(s, width))
+		if space := strings.LastIndex(s[:imin(cut+1, len(s))], " "); space > 0 {
+			cut = space
+		} else if cut == 0 {
+			_, cut = utf8.DecodeRuneInString(s)
+		}
+		result = append(result, strings.TrimRight(s[:cut], " "))
+		s = strings.TrimLeft(s[cut:], " ")
+	}
+	if len(s) > 0 {
+		result = append(result, s)
+	}
+	return result
+}
+
+// hintLabels returns `count` distinct labels made of the characters of `alphabet`, all of the same length:
+// single characters when there are enough of them, pairs otherwise
+func hintLabels(count int, alphabet string) []string {
+	chars := []rune(alphabet)
+	labels := make([]string, 0, count)
+	if count <= len(chars) {
+		for _, char := range chars[:count] {
+			labels = append(labels, string(char))
+		}
+		return labels
+	}
+	for _, first := range chars {
+		for _, second := range chars {
+			if len(labels) == count {
+				return labels
+			}
+			labels = append(labels, string([]rune{first, second}))
+		}
+	}
+	return labels
+}
+
+// completeWord returns the candidates starting with `word` and their longest common prefix, which is `word` itself when there are none
+func completeWord(word string, candidates []string) (string, []string) {
+	matches := []string{}
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, word) {
+			matches = append(matches, candidate)
+		}
+	}
+	if len(matches) == 0 {
+		return word, matches
+	}
+	prefix := matches[0]
+	for _, match := range matches[1:] {
+		for !strings.HasPrefix(match, prefix) {
+			_, size := utf8.DecodeLastRuneInString(prefix)
+			prefix = prefix[:len(prefix)-size]
+		}
+	}
+	return prefix, matches
+}
+
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// openerCommand splits the `opener` command into its arguments, `%s` is replaced by `address` which is added at the end otherwise
+func openerCommand(opener, address string) []string {
+	args := strings.Fields(opener)
+	replaced := false
+	for i := range args {
+		if strings.Contains(args[i], "%s") {
+			args[i] = strings.Replace(args[i], "%s", address, -1)
+			replaced = true
+		}
+	}
+	if len(args) > 0 && !replaced {
+		args = append(args, address)
+	}
+	return args
+}
+
+// normalizeAddress turns a user-provided gopher URL or `host[:port][/selector]` into a full address
+func normalizeAddress(input string) (string, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return "", fmt.Errorf("empty address")
+	}
+	if isLocalAddress(input) {
+		return input, nil
+	}
+	if strings.Contains(input, "://") {
+		url, err := core.ParseURL(input)
+		if err != nil {
+			return "", err
+		}
+		return url.String(), nil
+	}
+
+	authority, selector := input, ""
+	if i := strings.Index(input, "/"); i >= 0 {
+		authority, selector = input[:i], input[i:]
+	}
+	if selector == "/" {
+		selector = ""
+	}
+	url, err := core.ParseURL("gopher://" + authority)
+	if err != nil {
+		return "", err
+	}
+	url.Selector = selector
+	return url.String(), nil
+}
+
+// linkTypes guesses the type of the items linked from an HTML page from their extension
+var linkTypes = map[string]core.GopherEntry{
+	"":      core.TypeSubMenu,
+	".html": core.TypeHTML,
+	".htm":  core.TypeHTML,
+	".txt":  core.TypeFile,
+	".md":   core.TypeFile,
+	".gif":  core.TypeGIF,
+	".png":  core.TypeImage,
+	".jpg":  core.TypeImage,
+	".jpeg": core.TypeImage,
+}
+
+// resolveLink turns the `target` of a link found in the page at `base` into an absolute URL,
+// a relative one staying on the server of the page with a type guessed from its extension
+func resolveLink(base, target string) (string, error) {
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return "", fmt.Errorf("invalid link `%s`: %s", target, err)
+	}
+	if parsed.IsAbs() {
+		return target, nil
+	}
+	page, err := core.ParseURL(base)
+	if err != nil {
+		return "", fmt.Errorf("cannot follow relative link `%s` from `%s`", target, base)
+	}
+	if parsed.Host != "" {
+		return normalizeAddress(strings.SplitN(page.String(), ":", 2)[0] + ":" + target)
+	}
+
+	resolved := (&url.URL{Path: page.Selector}).ResolveReference(parsed)
+	if resolved.Path == page.Selector && target != "" && target[0] == '#' {
+		return base, nil
+	}
+	gtype, found := linkTypes[strings.ToLower(path.Ext(resolved.Path))]
+	if !found {
+		gtype = core.TypeBinary
+	}
+	if strings.HasSuffix(resolved.Path, "/") {
+		gtype = core.TypeSubMenu
+	}
+	selector := resolved.Path
+	if resolved.RawQuery != "" {
+		selector += "?" + resolved.RawQuery
 	}
-	return s + strings.Repeat(" ", total-length)
+	link := core.URL{TLS: page.TLS, Host: page.Host, Port: page.Port, Type: gtype, Selector: selector}
+	return link.String(), nil
 }
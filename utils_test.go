@@ -45,8 +45,168 @@ func TestLJust(t *testing.T) {
 		{"abc", 5, "abc  "},
 		{"abc", 3, "abc"},
 		{"abc", 2, "ab"},
+		{"café", 5, "café "},
+		{"日本語", 5, "日本 "},
+		{"日本語", 3, "日 "},
 	}
 	for _, test := range cases {
 		assert.Equal(t, test.output, ljust(test.input1, test.input2))
 	}
 }
+
+func TestExpandTabs(t *testing.T) {
+	cases := []struct {
+		input  string
+		output string
+	}{
+		{"abc", "abc"},
+		{"\tabc", "        abc"},
+		{"ab\tc", "ab      c"},
+		{"abcdefgh\ti", "abcdefgh        i"},
+		{"日本\tx", "日本    x"},
+	}
+	for _, test := range cases {
+		assert.Equal(t, test.output, expandTabs(test.input))
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	cases := []struct {
+		input  string
+		width  int
+		output []string
+	}{
+		{"", 10, []string{""}},
+		{"short", 10, []string{"short"}},
+		{"hello world foo", 11, []string{"hello world", "foo"}},
+		{"hello world foo", 8, []string{"hello", "world", "foo"}},
+		{"abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+		{"été à la mer", 7, []string{"été à", "la mer"}},
+		{"日本語のテキスト", 5, []string{"日本", "語の", "テキ", "スト"}},
+	}
+	for _, test := range cases {
+		assert.Equal(t, test.output, wrapText(test.input, test.width))
+	}
+}
+
+func TestHintLabels(t *testing.T) {
+	cases := []struct {
+		count  int
+		output []string
+	}{
+		{0, []string{}},
+		{2, []string{"a", "s"}},
+		{3, []string{"a", "s", "d"}},
+		{4, []string{"aa", "as", "ad", "sa"}},
+		{10, []string{"aa", "as", "ad", "sa", "ss", "sd", "da", "ds", "dd"}},
+	}
+	for _, test := range cases {
+		assert.Equal(t, test.output, hintLabels(test.count, "asd"), "Expected %d labels", test.count)
+	}
+}
+
+func TestCompleteWord(t *testing.T) {
+	candidates := []string{"open", "bookmark", "bookmarks", "back", "über", "übel"}
+	cases := []struct {
+		word      string
+		completed string
+		matches   []string
+	}{
+		{"o", "open", []string{"open"}},
+		{"bo", "bookmark", []string{"bookmark", "bookmarks"}},
+		{"b", "b", []string{"bookmark", "bookmarks", "back"}},
+		{"ü", "übe", []string{"über", "übel"}},
+		{"x", "x", []string{}},
+	}
+	for _, test := range cases {
+		completed, matches := completeWord(test.word, candidates)
+		assert.Equal(t, test.completed, completed, "Expected %q to be completed", test.word)
+		assert.Equal(t, test.matches, matches)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	cases := []struct {
+		input  int64
+		output string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{5 * 1024 * 1024, "5.0 MiB"},
+	}
+	for _, test := range cases {
+		assert.Equal(t, test.output, formatBytes(test.input))
+	}
+}
+
+func TestOpenerCommand(t *testing.T) {
+	cases := []struct {
+		opener string
+		output []string
+	}{
+		{"", []string{}},
+		{"xdg-open", []string{"xdg-open", "http://a/"}},
+		{"firefox --new-tab", []string{"firefox", "--new-tab", "http://a/"}},
+		{"w3m %s -title", []string{"w3m", "http://a/", "-title"}},
+	}
+	for _, test := range cases {
+		assert.Equal(t, test.output, openerCommand(test.opener, "http://a/"))
+	}
+}
+
+func TestNormalizeAddress(t *testing.T) {
+	cases := []struct {
+		input  string
+		output string
+	}{
+		{"gopher.floodgap.com", "gopher://gopher.floodgap.com/1"},
+		{"  gopher.floodgap.com/  ", "gopher://gopher.floodgap.com/1"},
+		{"sdf.org:7070/users", "gopher://sdf.org:7070/1/users"},
+		{"sdf.org/my file", "gopher://sdf.org/1/my%20file"},
+		{"gopher://sdf.org/0/phlogs", "gopher://sdf.org/0/phlogs"},
+		{"gopher://sdf.org:70/?q=/about&t=h", "gopher://sdf.org/h/about"},
+		{"[::1]:7070", "gopher://[::1]:7070/1"},
+		{"about:bookmarks/Phlogs", "about:bookmarks/Phlogs"},
+	}
+	for _, test := range cases {
+		output, err := normalizeAddress(test.input)
+		assert.NoError(t, err, "Expected %q to be normalized", test.input)
+		assert.Equal(t, test.output, output)
+	}
+}
+
+func TestNormalizeAddressFailure(t *testing.T) {
+	cases := []string{"", "   ", "http://example.com/", "gopher:///selector"}
+	for _, test := range cases {
+		_, err := normalizeAddress(test)
+		assert.Error(t, err, "Expected %q to be rejected", test)
+	}
+}
+
+func TestResolveLink(t *testing.T) {
+	cases := []struct {
+		base   string
+		target string
+		output string
+	}{
+		{"gopher://sdf.org/h/docs/index.html", "https://example.com/", "https://example.com/"},
+		{"gopher://sdf.org/h/docs/index.html", "intro.html", "gopher://sdf.org/h/docs/intro.html"},
+		{"gopher://sdf.org/h/docs/index.html", "../notes.txt", "gopher://sdf.org/0/notes.txt"},
+		{"gopher://sdf.org/h/docs/index.html", "/files/", "gopher://sdf.org/1/files/"},
+		{"gopher://sdf.org/h/docs/index.html", "logo.png", "gopher://sdf.org/I/docs/logo.png"},
+		{"gopher://sdf.org/h/docs/index.html", "archive.zip", "gopher://sdf.org/9/docs/archive.zip"},
+		{"gophers://sdf.org:7070/h/index.html", "//floodgap.com/1/", "gophers://floodgap.com/1/"},
+		{"gopher://sdf.org/h/docs/index.html", "#top", "gopher://sdf.org/h/docs/index.html"},
+	}
+	for _, test := range cases {
+		output, err := resolveLink(test.base, test.target)
+		if assert.NoError(t, err, "Expected %q to be resolved from %q", test.target, test.base) {
+			assert.Equal(t, test.output, output)
+		}
+	}
+
+	_, err := resolveLink("about:history", "intro.html")
+	assert.Error(t, err)
+}